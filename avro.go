@@ -0,0 +1,256 @@
+package structparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// AvroOptions configures GenerateAvro.
+type AvroOptions struct {
+	// Namespace overrides the namespace derived from the package import path
+	// (e.g., "github.com/acme/events" becomes "github.com.acme.events").
+	Namespace string
+}
+
+type avroRecord struct {
+	Type      string      `json:"type"`
+	Name      string      `json:"name"`
+	Namespace string      `json:"namespace,omitempty"`
+	Doc       string      `json:"doc,omitempty"`
+	Fields    []avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    interface{}     `json:"type"`
+	Doc     string          `json:"doc,omitempty"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+type avroEnum struct {
+	Type      string   `json:"type"`
+	Name      string   `json:"name"`
+	Namespace string   `json:"namespace,omitempty"`
+	Doc       string   `json:"doc,omitempty"`
+	Symbols   []string `json:"symbols"`
+}
+
+var (
+	avroSymbol       = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	avroInvalidChars = regexp.MustCompile(`[^A-Za-z0-9_]`)
+)
+
+// GenerateAvro produces one Avro record schema (.avsc) per exported struct, keyed by the
+// record's full name. Nested structs and enums are defined inline on first use and
+// referenced by name afterwards. Pointer fields become unions with null defaulting to
+// null, time.Time becomes a timestamp-millis long. Field names come from the `avro` tag;
+// `avro:"-"` skips a field.
+func GenerateAvro(pkg *Package, opts AvroOptions) (map[string][]byte, error) {
+	if opts.Namespace == "" {
+		opts.Namespace = avroNamespace(pkg)
+	}
+
+	structs := map[string]Struct{}
+	for _, s := range pkg.Structs {
+		structs[s.Name] = s
+	}
+	enums := map[string]Enum{}
+	for _, e := range pkg.Enums {
+		enums[e.Name] = e
+	}
+
+	schemas := map[string][]byte{}
+	for _, s := range pkg.Structs {
+		if !ast.IsExported(s.Name) {
+			continue
+		}
+		g := &avroGenerator{
+			namespace: opts.Namespace,
+			structs:   structs,
+			enums:     enums,
+			defined:   map[string]bool{},
+		}
+		record, err := g.record(s)
+		if err != nil {
+			return nil, err
+		}
+		encoded, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		name := s.Name
+		if opts.Namespace != "" {
+			name = opts.Namespace + "." + name
+		}
+		schemas[name] = encoded
+	}
+	return schemas, nil
+}
+
+// avroNamespace converts the package import path (or name) to a dotted Avro namespace.
+func avroNamespace(pkg *Package) string {
+	path := pkg.Path
+	if path == "" {
+		path = pkg.Package
+	}
+	parts := strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '.' })
+	for i, part := range parts {
+		part = avroInvalidChars.ReplaceAllString(part, "_")
+		if part[0] >= '0' && part[0] <= '9' {
+			part = "_" + part
+		}
+		parts[i] = part
+	}
+	return strings.Join(parts, ".")
+}
+
+type avroGenerator struct {
+	namespace string
+	structs   map[string]Struct
+	enums     map[string]Enum
+	defined   map[string]bool // Named types already defined in the schema being built
+}
+
+func (g *avroGenerator) record(s Struct) (*avroRecord, error) {
+	g.defined[s.Name] = true
+	record := &avroRecord{
+		Type:      "record",
+		Name:      s.Name,
+		Namespace: g.namespace,
+		Doc:       strings.Join(s.Docs, "\n"),
+		Fields:    make([]avroField, 0, len(s.Fields)),
+	}
+	for _, f := range s.Fields {
+		if f.Name == "" {
+			f.Name = embeddedTypeName(f)
+		}
+		if f.Private {
+			continue
+		}
+		name := f.Name
+		if tagName, _, ok := lookupTag(f.Tag, "avro"); ok {
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		typ, err := g.typeOf(parseTypeString(f.Type))
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", s.Name, f.Name, err)
+		}
+		docs := append([]string{}, f.Docs...)
+		if f.Comment != "" {
+			docs = append(docs, f.Comment)
+		}
+		field := avroField{Name: name, Type: typ, Doc: strings.Join(docs, "\n")}
+		if union, ok := typ.([]interface{}); ok && union[0] == "null" {
+			field.Default = json.RawMessage("null")
+		}
+		record.Fields = append(record.Fields, field)
+	}
+	return record, nil
+}
+
+// typeOf maps a Go type expression to an Avro schema.
+func (g *avroGenerator) typeOf(expr ast.Expr) (interface{}, error) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		inner, err := g.typeOf(e.X)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"null", inner}, nil
+	case *ast.Ident:
+		switch e.Name {
+		case "string":
+			return "string", nil
+		case "bool":
+			return "boolean", nil
+		case "int8", "int16", "int32", "uint8", "uint16", "byte", "rune":
+			return "int", nil
+		case "int", "int64", "uint", "uint32", "uint64":
+			return "long", nil
+		case "float32":
+			return "float", nil
+		case "float64":
+			return "double", nil
+		}
+		if e, ok := g.enums[e.Name]; ok {
+			return g.enum(e), nil
+		}
+		if s, ok := g.structs[e.Name]; ok {
+			if g.defined[s.Name] {
+				return s.Name, nil
+			}
+			return g.record(s)
+		}
+	case *ast.SelectorExpr:
+		switch justTypeString(getType(e)) {
+		case "time.Time":
+			return map[string]string{"type": "long", "logicalType": "timestamp-millis"}, nil
+		case "time.Duration":
+			return "long", nil
+		case "uuid.UUID":
+			return map[string]string{"type": "string", "logicalType": "uuid"}, nil
+		}
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return "bytes", nil
+		}
+		items, err := g.typeOf(e.Elt)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case *ast.MapType:
+		if key, ok := e.Key.(*ast.Ident); !ok || key.Name != "string" {
+			return nil, fmt.Errorf("avro maps require string keys, got %s", justTypeString(getType(e.Key)))
+		}
+		values, err := g.typeOf(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "map", "values": values}, nil
+	}
+	if expr == nil {
+		return nil, fmt.Errorf("unsupported type")
+	}
+	return nil, fmt.Errorf("unsupported type %s", justTypeString(getType(expr)))
+}
+
+// enum maps string enums whose values are valid symbols to Avro enums and everything
+// else to the underlying primitive.
+func (g *avroGenerator) enum(e Enum) interface{} {
+	if e.Type != "string" {
+		if e.Type == "int64" || e.Type == "uint64" || e.Type == "uint" || e.Type == "int" || e.Type == "uint32" {
+			return "long"
+		}
+		return "int"
+	}
+	if g.defined[e.Name] {
+		return e.Name
+	}
+	symbols := make([]string, 0, len(e.Values))
+	for _, v := range e.Values {
+		symbol, err := strconv.Unquote(v.Value)
+		if err != nil || !avroSymbol.MatchString(symbol) {
+			return "string"
+		}
+		symbols = append(symbols, symbol)
+	}
+	g.defined[e.Name] = true
+	return avroEnum{
+		Type:      "enum",
+		Name:      e.Name,
+		Namespace: g.namespace,
+		Doc:       strings.Join(e.Docs, "\n"),
+		Symbols:   symbols,
+	}
+}
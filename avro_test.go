@@ -0,0 +1,109 @@
+package structparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const eventsCode = `
+package events
+
+type Kind string
+
+const (
+	KindCreated Kind = "created"
+	KindDeleted Kind = "deleted"
+)
+
+type Actor struct {
+	ID string ` + "`avro:\"id\" parquet:\"id\"`" + `
+}
+
+// OrderEvent is published on every order change
+type OrderEvent struct {
+	Kind       Kind              ` + "`avro:\"kind\" parquet:\"kind\"`" + `
+	OrderID    int64             ` + "`avro:\"order_id\" parquet:\"name=order_id, type=INT64\"`" + `
+	Note       *string           ` + "`avro:\"note\" parquet:\"note\"`" + `
+	Tags       []string          ` + "`avro:\"tags\" parquet:\"tags\"`" + `
+	Attrs      map[string]int32  ` + "`avro:\"attrs\" parquet:\"attrs\"`" + `
+	Actor      Actor             ` + "`avro:\"actor\" parquet:\"actor\"`" + `
+	Previous   *Actor            ` + "`avro:\"previous\" parquet:\"previous\"`" + `
+	OccurredAt time.Time         ` + "`avro:\"occurred_at\" parquet:\"occurred_at\"`" + `
+	Debug      string            ` + "`avro:\"-\" parquet:\"-\"`" + `
+}
+`
+
+func TestGenerateAvro(t *testing.T) {
+	output, err := ParseString(eventsCode)
+	require.NoError(t, err)
+	pkg := output.Packages[0]
+	pkg.Path = "github.com/acme/order-events/events"
+
+	schemas, err := GenerateAvro(&pkg, AvroOptions{})
+	require.NoError(t, err)
+	require.Contains(t, schemas, "github.com.acme.order_events.events.Actor")
+
+	var record map[string]interface{}
+	require.NoError(t, json.Unmarshal(schemas["github.com.acme.order_events.events.OrderEvent"], &record))
+	require.Equal(t, "record", record["type"])
+	require.Equal(t, "OrderEvent", record["name"])
+	require.Equal(t, "github.com.acme.order_events.events", record["namespace"])
+	require.Equal(t, "OrderEvent is published on every order change", record["doc"])
+
+	fields := record["fields"].([]interface{})
+	require.Len(t, fields, 8)
+	byName := map[string]map[string]interface{}{}
+	for _, f := range fields {
+		byName[f.(map[string]interface{})["name"].(string)] = f.(map[string]interface{})
+	}
+	require.Equal(t, map[string]interface{}{"type": "enum", "name": "Kind", "namespace": "github.com.acme.order_events.events", "symbols": []interface{}{"created", "deleted"}}, byName["kind"]["type"])
+	require.Equal(t, "long", byName["order_id"]["type"])
+	require.Equal(t, []interface{}{"null", "string"}, byName["note"]["type"])
+	require.Contains(t, byName["note"], "default")
+	require.Nil(t, byName["note"]["default"])
+	require.Equal(t, map[string]interface{}{"type": "array", "items": "string"}, byName["tags"]["type"])
+	require.Equal(t, map[string]interface{}{"type": "map", "values": "int"}, byName["attrs"]["type"])
+	require.Equal(t, "record", byName["actor"]["type"].(map[string]interface{})["type"])
+	require.Equal(t, []interface{}{"null", "Actor"}, byName["previous"]["type"])
+	require.Equal(t, map[string]interface{}{"type": "long", "logicalType": "timestamp-millis"}, byName["occurred_at"]["type"])
+
+	t.Run("Namespace", func(t *testing.T) {
+		schemas, err := GenerateAvro(&pkg, AvroOptions{Namespace: "com.acme"})
+		require.NoError(t, err)
+		require.Contains(t, schemas, "com.acme.OrderEvent")
+	})
+}
+
+func TestGenerateParquet(t *testing.T) {
+	output, err := ParseString(eventsCode)
+	require.NoError(t, err)
+
+	schemas, err := GenerateParquet(&output.Packages[0])
+	require.NoError(t, err)
+	require.Equal(t, `message OrderEvent {
+  required binary kind (STRING);
+  required int64 order_id;
+  optional binary note (STRING);
+  required group tags (LIST) {
+    repeated group list {
+      required binary element (STRING);
+    }
+  }
+  required group attrs (MAP) {
+    repeated group key_value {
+      required binary key (STRING);
+      required int32 value;
+    }
+  }
+  required group actor {
+    required binary id (STRING);
+  }
+  optional group previous {
+    required binary id (STRING);
+  }
+  required int64 occurred_at (TIMESTAMP(MILLIS,true));
+}
+`, string(schemas["OrderEvent"]))
+}
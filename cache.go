@@ -0,0 +1,145 @@
+package structparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cacheFormat changes whenever cached entries would be read differently, for instance
+// when extraction starts filling a new field.
+const cacheFormat = "4"
+
+// Cache stores the declarations extracted from files on disk, content addressed: an entry
+// is keyed by the hash of the name and content of a file. Packages are put together from
+// the entries of their files on every parse, so editing a file re-parses that file only.
+// Options such as GOOS or Tests choose which files make up a package and do not change
+// what is extracted from a file, so entries are shared between them. A Cache is safe for
+// concurrent use, including by several processes.
+type Cache struct {
+	dir string
+}
+
+// CacheStats describes the content of a cache directory.
+type CacheStats struct {
+	Dir     string `json:"dir"`
+	Entries int    `json:"entries"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// DefaultCacheDir returns the structparser directory under os.UserCacheDir.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "structparser"), nil
+}
+
+// OpenCache returns the cache stored in dir, creating the directory if needed.
+func OpenCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Cache{dir: dir}, nil
+}
+
+// Dir returns the directory of the cache.
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// Clean removes every entry of the cache.
+func (c *Cache) Clean() error {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(c.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stats counts the entries of the cache and their size.
+func (c *Cache) Stats() (CacheStats, error) {
+	stats := CacheStats{Dir: c.dir}
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".json") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stats.Entries++
+		stats.Bytes += info.Size()
+		return nil
+	})
+	return stats, err
+}
+
+// key hashes the name and content of a file.
+func (c *Cache) key(f sourceFile) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "structparser %s/%s\n", SchemaVersion, cacheFormat)
+	sum := sha256.Sum256(f.content)
+	fmt.Fprintf(h, "%q %x\n", f.name, sum)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+".json")
+}
+
+// get returns the cached declarations for key, or false when there are none or they are
+// unreadable.
+func (c *Cache) get(key string) (fileDecls, bool) {
+	content, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return fileDecls{}, false
+	}
+	var decls fileDecls
+	if err := json.Unmarshal(content, &decls); err != nil {
+		return fileDecls{}, false
+	}
+	return decls, true
+}
+
+// put stores decls under key. The entry is written to a temporary file and renamed so
+// that readers never see a partial entry.
+func (c *Cache) put(key string, decls fileDecls) error {
+	content, err := json.Marshal(decls)
+	if err != nil {
+		return err
+	}
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
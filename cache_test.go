@@ -0,0 +1,78 @@
+package structparser
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache(t *testing.T) {
+	cache, err := OpenCache(filepath.Join(t.TempDir(), "cache"))
+	require.NoError(t, err)
+	dirs := writePackages(t, 2)
+	store := filepath.Join(dirs[0], "store.go")
+	require.NoError(t, os.WriteFile(store, []byte("package pkg00\n\nfunc (u *User) Load() error { return nil }\n"), 0o644))
+	opts := ParseOptions{Cache: cache}
+
+	fresh, err := ParseDirectory(dirs[0])
+	require.NoError(t, err)
+	first, err := opts.ParseDirectory(dirs[0])
+	require.NoError(t, err)
+	second, err := opts.ParseDirectory(dirs[0])
+	require.NoError(t, err)
+	for _, output := range []*Output{first, second} {
+		expected, _ := json.Marshal(fresh)
+		actual, _ := json.Marshal(output)
+		require.JSONEq(t, string(expected), string(actual))
+	}
+	require.Len(t, second.Packages[0].Structs[0].Methods, 2)
+
+	stats, err := cache.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.Entries)
+	require.Positive(t, stats.Bytes)
+
+	// Unchanged files are not parsed again: a doctored entry is used as is, and still
+	// linked with the other files of the package
+	user := filepath.Join(dirs[0], "user.go")
+	content, err := os.ReadFile(user)
+	require.NoError(t, err)
+	key := cache.key(sourceFile{name: user, content: content})
+	decls, ok := cache.get(key)
+	require.True(t, ok)
+	decls.Types[0].Struct.Fields[0].Name = "Cached"
+	require.NoError(t, cache.put(key, decls))
+	output, err := opts.ParseDirectory(dirs[0])
+	require.NoError(t, err)
+	require.Equal(t, "Cached", output.Packages[0].Structs[0].Fields[0].Name)
+
+	// Only the changed file is parsed again
+	require.NoError(t, os.WriteFile(store, []byte("package pkg00\n\nfunc (u *User) Delete() error { return nil }\n"), 0o644))
+	output, err = opts.ParseDirectory(dirs[0])
+	require.NoError(t, err)
+	s := output.Packages[0].Structs[0]
+	require.Equal(t, "Cached", s.Fields[0].Name)
+	require.Equal(t, []string{"Delete", "Save"}, []string{s.Methods[0].Name, s.Methods[1].Name})
+	stats, err = cache.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 3, stats.Entries)
+
+	// Options choose files but do not change their entries
+	_, err = ParseOptions{Cache: cache, BuildConstraints: true, GOOS: "plan9"}.ParseDirectory(dirs[1])
+	require.NoError(t, err)
+	_, err = ParseOptions{Cache: cache, Tests: true}.ParseDirectory(dirs[1])
+	require.NoError(t, err)
+	stats, err = cache.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 4, stats.Entries)
+
+	require.NoError(t, cache.Clean())
+	stats, err = cache.Stats()
+	require.NoError(t, err)
+	require.Equal(t, 0, stats.Entries)
+	_, err = os.Stat(cache.Dir())
+	require.NoError(t, err)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/wricardo/structparser"
+)
+
+// runCache implements `structparser cache clean|stats` and returns the exit code.
+func runCache(args []string) int {
+	flags := flag.NewFlagSet("cache", flag.ExitOnError)
+	dir := flags.String("dir", "", "cache directory (default $XDG_CACHE_HOME/structparser or equivalent)")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: structparser cache [-dir path] clean|stats\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	cache, err := openCache(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	switch flags.Arg(0) {
+	case "clean":
+		err = cache.Clean()
+	case "stats":
+		var stats structparser.CacheStats
+		stats, err = cache.Stats()
+		if err == nil {
+			fmt.Printf("dir:     %s\nentries: %d\nsize:    %d bytes\n", stats.Dir, stats.Entries, stats.Bytes)
+		}
+	default:
+		flags.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+// openCache opens dir, or the default cache directory when dir is empty.
+func openCache(dir string) (*structparser.Cache, error) {
+	if dir == "" {
+		var err error
+		if dir, err = structparser.DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	return structparser.OpenCache(dir)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/wricardo/structparser"
+)
+
+// snapshotEncodings maps snapshot file extensions to encoding names.
+var snapshotEncodings = map[string]string{
+	".json":    "json",
+	".yaml":    "yaml",
+	".yml":     "yaml",
+	".toml":    "toml",
+	".msgpack": "msgpack",
+	".mp":      "msgpack",
+}
+
+// runDiff compares two snapshots or directories and returns the exit code: 0 when the
+// new API is compatible, 1 when it has breaking changes and 2 on errors.
+func runDiff(args []string) int {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	format := flags.String("format", "text", "report format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: structparser diff [-format text|json] old new\n\nold and new are directories, rev:dir git revisions (e.g., HEAD~1:./pkg)\nor snapshot files (%s).\nExits with status 1 when there are breaking changes.\n\n", strings.Join(snapshotExtensions(), ", "))
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2
+	}
+
+	before, err := loadSnapshot(flags.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	after, err := loadSnapshot(flags.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	changes := structparser.Diff(before, after)
+	switch *format {
+	case "text":
+		err = structparser.WriteChangeReport(os.Stdout, changes)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if changes == nil {
+			changes = []structparser.Change{}
+		}
+		err = enc.Encode(changes)
+	default:
+		err = fmt.Errorf("unknown format %q, expected text or json", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if structparser.HasBreakingChanges(changes) {
+		return 1
+	}
+	return 0
+}
+
+// loadSnapshot parses a directory or Go file, a directory at a git revision written as
+// "rev:dir", or decodes a snapshot file by extension.
+func loadSnapshot(path string) (*structparser.Output, error) {
+	if _, err := os.Stat(path); err != nil {
+		if rev, dir, ok := strings.Cut(path, ":"); ok && rev != "" {
+			return loadRevision(rev, dir)
+		}
+	}
+	name, ok := snapshotEncodings[strings.ToLower(filepath.Ext(path))]
+	if !ok {
+		return structparser.ParseDirectoryWithFilter(path, nil)
+	}
+	enc, _ := structparser.LookupEncoding(name)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	out, err := enc.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return out, nil
+}
+
+// loadRevision parses dir as it is at rev, reading the tree with git archive.
+func loadRevision(rev, dir string) (*structparser.Output, error) {
+	if dir == "" {
+		dir = "."
+	}
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	top, err := exec.Command("git", "-C", abs, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s is not inside a git repository", dir)
+	}
+	root := strings.TrimSpace(string(top))
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return nil, err
+	}
+	fsys, err := structparser.GitFS(root, rev)
+	if err != nil {
+		return nil, err
+	}
+	return structparser.ParseFS(fsys, filepath.ToSlash(rel))
+}
+
+func snapshotExtensions() []string {
+	exts := make([]string, 0, len(snapshotEncodings))
+	for ext := range snapshotEncodings {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	gobuild "go/build"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/wricardo/structparser"
+)
+
+// runLayout reports the memory layout of structs and returns the exit code: 0 when no
+// struct can be made smaller by reordering its fields, 1 when some can and 2 on errors,
+// including packages that failed to parse.
+func runLayout(args []string) int {
+	flags := flag.NewFlagSet("layout", flag.ExitOnError)
+	build := addBuildFlags(flags)
+	all := flags.Bool("all", false, "report every struct, not only those wasting padding")
+	format := flags.String("format", "text", "report format: text or json")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: structparser layout [-goarch arch] [-all] [-format text|json] [dir | file | dir/...] ...\n\nReports structs whose fields could be reordered to use less memory, with the\nsuggested order, for -goarch. Exits with status 1 when there are such structs.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	var opts structparser.ParseOptions
+	build.apply(&opts)
+	goarch := opts.GOARCH
+	if goarch == "" {
+		goarch = gobuild.Default.GOARCH
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	parsed, err := structparser.ParseContext(ctx, opts, flags.Args()...)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	failed := printDiagnostics(parsed)
+	layouts, err := structparser.AnalyzeLayout(parsed, goarch)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	wasteful := false
+	reported := []structparser.StructLayout{}
+	for _, l := range layouts {
+		if l.OptimalOrder != nil {
+			wasteful = true
+		}
+		if *all || l.OptimalOrder != nil {
+			reported = append(reported, l)
+		}
+	}
+	switch *format {
+	case "text":
+		for _, l := range reported {
+			fmt.Printf("%s:%d: %s.%s: ", l.File, l.Line, l.Package, l.Name)
+			switch {
+			case l.Unresolved != "":
+				fmt.Printf("unknown layout: %s\n", l.Unresolved)
+			case l.OptimalOrder != nil:
+				fmt.Printf("%d bytes with %d bytes of padding, could be %d bytes\n\tsuggested order: %s\n", l.Size, l.Padding, l.OptimalSize, strings.Join(l.OptimalOrder, ", "))
+			default:
+				fmt.Printf("%d bytes with %d bytes of padding\n", l.Size, l.Padding)
+			}
+		}
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(reported)
+	default:
+		err = fmt.Errorf("unknown format %q, expected text or json", *format)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if failed {
+		return 2
+	}
+	if wasteful {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+
+	"github.com/wricardo/structparser"
+)
+
+// runLSP implements `structparser lsp`, a language server over stdio, and returns the
+// exit code.
+func runLSP(args []string) int {
+	flags := flag.NewFlagSet("lsp", flag.ExitOnError)
+	tests := flags.Bool("tests", false, "include _test.go files and catalog their tests")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: structparser lsp [-tests]\n\nRuns a Language Server Protocol server on stdin and stdout.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	err := structparser.ServeLSP(ctx, os.Stdin, os.Stdout, structparser.ParseOptions{Tests: *tests})
+	if err != nil && !errors.Is(err, io.EOF) && ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
@@ -1,18 +1,120 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"flag"
 	"fmt"
 	"log"
+	"os"
+	"os/signal"
+	"strings"
 
 	"github.com/wricardo/structparser"
 )
 
 func main() {
-	parsed, err := structparser.ParseDirectoryWithFilter("./", nil)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			os.Exit(runDiff(os.Args[2:]))
+		case "cache":
+			os.Exit(runCache(os.Args[2:]))
+		case "watch":
+			os.Exit(runWatch(os.Args[2:]))
+		case "serve":
+			os.Exit(runServe(os.Args[2:]))
+		case "lsp":
+			os.Exit(runLSP(os.Args[2:]))
+		case "layout":
+			os.Exit(runLayout(os.Args[2:]))
+		}
+	}
+
+	format := flag.String("format", "json", "output format: "+strings.Join(structparser.Encodings(), ", "))
+	build := addBuildFlags(flag.CommandLine)
+	tests := flag.Bool("tests", false, "include _test.go files and catalog their tests")
+	layout := flag.Bool("layout", false, "include the size, alignment and field offsets of structs for -goarch")
+	workers := flag.Int("workers", 0, "directories parsed concurrently (default GOMAXPROCS)")
+	useCache := flag.Bool("cache", true, "read the declarations of unchanged files from the parse cache instead of parsing them again")
+	cacheDir := flag.String("cachedir", "", "parse cache directory (default $XDG_CACHE_HOME/structparser or equivalent)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: structparser [-format name] [-goos os] [-goarch arch] [-tags list] [-tests] [-layout] [-workers n] [-cache=false] [-cachedir path] [dir | file | dir/...] ...\n       structparser diff [-format text|json] old new\n       structparser cache [-dir path] clean|stats\n       structparser watch [flags] [dir | file | dir/...] ...\n       structparser serve [flags] [dir | file | dir/...] ...\n       structparser lsp [-tests]\n       structparser layout [-goarch arch] [-all] [-format text|json] [dir | file | dir/...] ...\n\n")
+		fmt.Fprintf(flag.CommandLine.Output(), "The parse cache is on by default and written to the structparser directory of the user\ncache directory ($XDG_CACHE_HOME, ~/.cache, ~/Library/Caches or %%LocalAppData%%); -cache=false\nturns it off and -cachedir moves it.\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	enc, ok := structparser.LookupEncoding(*format)
+	if !ok {
+		log.Fatalf("unknown format %q, expected one of %s", *format, strings.Join(structparser.Encodings(), ", "))
+	}
+
+	opts := structparser.ParseOptions{Tests: *tests, Layout: *layout, Workers: *workers}
+	build.apply(&opts)
+	if *useCache {
+		// Without a usable cache directory, everything is parsed
+		if cache, err := openCache(*cacheDir); err == nil {
+			opts.Cache = cache
+		}
+	}
+	var (
+		parsed *structparser.Output
+		err    error
+	)
+	switch {
+	case flag.NArg() == 0:
+		parsed, err = opts.ParseDirectory("./")
+	case flag.NArg() == 1 && !strings.HasSuffix(flag.Arg(0), "..."):
+		parsed, err = opts.ParseDirectory(flag.Arg(0))
+	default:
+		// Packages that fail to parse are listed in the diagnostics of the output
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		parsed, err = structparser.ParseContext(ctx, opts, flag.Args()...)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
-	encoded, _ := json.Marshal(parsed)
-	fmt.Println(string(encoded))
+	if err := enc.Encode(os.Stdout, parsed); err != nil {
+		log.Fatal(err)
+	}
+	// The packages that parsed are still written, but the run failed
+	if printDiagnostics(parsed) {
+		os.Exit(1)
+	}
+}
+
+// printDiagnostics writes the diagnostics of out to stderr and reports whether there were
+// any.
+func printDiagnostics(out *structparser.Output) bool {
+	for _, d := range out.Diagnostics {
+		if d.Line > 0 {
+			fmt.Fprintf(os.Stderr, "%s:%d: %s\n", d.File, d.Line, d.Message)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", d.File, d.Message)
+		}
+	}
+	return len(out.Diagnostics) > 0
+}
+
+// buildFlags are the -goos, -goarch and -tags flags of the commands that parse packages.
+// Files are selected by build constraints only when one of them is given.
+type buildFlags struct {
+	goos, goarch, tags *string
+}
+
+func addBuildFlags(flags *flag.FlagSet) buildFlags {
+	return buildFlags{
+		goos:   flags.String("goos", "", "select files by build constraints for this operating system (default $GOOS or the host's)"),
+		goarch: flags.String("goarch", "", "select files by build constraints for this architecture (default $GOARCH or the host's)"),
+		tags:   flags.String("tags", "", "select files by build constraints with this comma-separated list of extra build tags"),
+	}
+}
+
+func (b buildFlags) apply(opts *structparser.ParseOptions) {
+	opts.GOOS, opts.GOARCH = *b.goos, *b.goarch
+	if *b.tags != "" {
+		opts.BuildTags = strings.Split(*b.tags, ",")
+	}
+	opts.BuildConstraints = opts.GOOS != "" || opts.GOARCH != "" || opts.BuildTags != nil
 }
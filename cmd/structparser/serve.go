@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/wricardo/structparser"
+)
+
+// runServe implements `structparser serve` and returns the exit code.
+func runServe(args []string) int {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	build := addBuildFlags(flags)
+	addr := flags.String("addr", "localhost:8080", "address to listen on")
+	tests := flags.Bool("tests", false, "include _test.go files and catalog their tests")
+	watch := flags.Bool("watch", false, "refresh the served data when Go files change")
+	poll := flags.Bool("poll", false, "with -watch, poll for changes instead of using file system notifications")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: structparser serve [flags] [dir | file | dir/...] ...\n\nEndpoints: /packages, /structs/{pkg}/{name}, /interfaces/{name}/implementors, /search?q=\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts := structparser.ParseOptions{Tests: *tests}
+	build.apply(&opts)
+	ws := structparser.NewWorkspace(opts)
+	if _, err := ws.Load(ctx, flags.Args()...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	for _, d := range ws.Output().Diagnostics {
+		log.Printf("%s:%d: %s", d.File, d.Line, d.Message)
+	}
+
+	if *watch {
+		go func() {
+			err := ws.Watch(ctx, structparser.WatchOptions{Poll: *poll}, func(changes structparser.ChangeSet, err error) {
+				if err != nil {
+					log.Print(err)
+				}
+				if !changes.Empty() {
+					log.Printf("%d added, %d removed, %d modified", len(changes.Added), len(changes.Removed), len(changes.Modified))
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				log.Printf("watch: %v", err)
+			}
+		}()
+	}
+
+	server := &http.Server{Addr: *addr, Handler: structparser.NewServer(ws), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+	log.Printf("serving on http://%s", *addr)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wricardo/structparser"
+)
+
+// generators turn the parsed output into a file for `structparser watch -gen`.
+var generators = map[string]func(*structparser.Output) ([]byte, error){
+	"typescript": func(out *structparser.Output) ([]byte, error) {
+		var buf bytes.Buffer
+		for i := range out.Packages {
+			content, err := structparser.GenerateTypeScript(&out.Packages[i], structparser.TypeScriptOptions{})
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(content)
+		}
+		return buf.Bytes(), nil
+	},
+	"sql": func(out *structparser.Output) ([]byte, error) {
+		return structparser.GenerateSQL(out, structparser.SQLOptions{})
+	},
+	"graphql": func(out *structparser.Output) ([]byte, error) {
+		return structparser.GenerateGraphQL(out, structparser.GraphQLOptions{})
+	},
+	"openapi": func(out *structparser.Output) ([]byte, error) {
+		return structparser.GenerateOpenAPI(out, structparser.OpenAPIOptions{})
+	},
+	"diagram": func(out *structparser.Output) ([]byte, error) {
+		return structparser.GenerateDiagram(out, structparser.DiagramOptions{})
+	},
+}
+
+// genFlag collects repeated -gen name=path flags.
+type genFlag []string
+
+func (g *genFlag) String() string     { return strings.Join(*g, ",") }
+func (g *genFlag) Set(v string) error { *g = append(*g, v); return nil }
+
+// runWatch implements `structparser watch` and returns the exit code.
+func runWatch(args []string) int {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	build := addBuildFlags(flags)
+	format := flags.String("format", "json", "output format: "+strings.Join(structparser.Encodings(), ", "))
+	output := flags.String("o", "", "write the parsed output to this file instead of stdout")
+	tests := flags.Bool("tests", false, "include _test.go files and catalog their tests")
+	debounce := flags.Duration("debounce", 100*time.Millisecond, "quiet period before regenerating")
+	interval := flags.Duration("interval", time.Second, "polling interval, when polling")
+	poll := flags.Bool("poll", false, "poll for changes instead of using file system notifications")
+	var gens genFlag
+	flags.Var(&gens, "gen", "generate name=path on every change (repeatable); names: "+strings.Join(generatorNames(), ", "))
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: structparser watch [flags] [dir | file | dir/...] ...\n\nRegenerates the output and the -gen files whenever a Go file changes.\n\n")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	enc, ok := structparser.LookupEncoding(*format)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown format %q, expected one of %s\n", *format, strings.Join(structparser.Encodings(), ", "))
+		return 2
+	}
+	targets := map[string]string{}
+	for _, g := range gens {
+		name, path, ok := strings.Cut(g, "=")
+		if _, known := generators[name]; !ok || !known || path == "" {
+			fmt.Fprintf(os.Stderr, "invalid -gen %q, expected name=path with name one of %s\n", g, strings.Join(generatorNames(), ", "))
+			return 2
+		}
+		targets[name] = path
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	opts := structparser.ParseOptions{Tests: *tests}
+	build.apply(&opts)
+	ws := structparser.NewWorkspace(opts)
+	if _, err := ws.Load(ctx, flags.Args()...); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	emit := func() {
+		out := ws.Output()
+		var buf bytes.Buffer
+		if err := enc.Encode(&buf, out); err != nil {
+			log.Print(err)
+			return
+		}
+		if *output == "" {
+			os.Stdout.Write(buf.Bytes())
+		} else if err := writeIfChanged(*output, buf.Bytes()); err != nil {
+			log.Print(err)
+		}
+		for name, path := range targets {
+			content, err := generators[name](out)
+			if err == nil {
+				err = writeIfChanged(path, content)
+			}
+			if err != nil {
+				log.Printf("%s: %v", name, err)
+			}
+		}
+	}
+	emit()
+	err := ws.Watch(ctx, structparser.WatchOptions{Debounce: *debounce, Interval: *interval, Poll: *poll},
+		func(changes structparser.ChangeSet, err error) {
+			if err != nil {
+				// Keep the last good output until the package parses again
+				log.Print(err)
+			}
+			if !changes.Empty() {
+				log.Printf("%d added, %d removed, %d modified", len(changes.Added), len(changes.Removed), len(changes.Modified))
+				emit()
+			}
+		})
+	if err != nil && ctx.Err() == nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	return 0
+}
+
+func generatorNames() []string {
+	names := make([]string, 0, len(generators))
+	for name := range generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// writeIfChanged writes content to path unless it already holds it, so that tools
+// watching the generated files are not woken up for nothing.
+func writeIfChanged(path string, content []byte) error {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, content) {
+		return nil
+	}
+	return os.WriteFile(path, content, 0o644)
+}
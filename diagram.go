@@ -0,0 +1,303 @@
+package structparser
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DiagramFormat selects the output of GenerateDiagram.
+type DiagramFormat string
+
+const (
+	DiagramDOT     DiagramFormat = "dot"
+	DiagramMermaid DiagramFormat = "mermaid"
+)
+
+// DiagramOptions configures GenerateDiagram.
+type DiagramOptions struct {
+	// Format defaults to DiagramMermaid.
+	Format DiagramFormat
+	// Root limits the diagram to types reachable from this type, given as "Name",
+	// "pkg.Name" or "import/path.Name". Empty includes every type.
+	Root string
+	// Depth is the maximum number of edges followed from Root. Zero means unlimited.
+	Depth int
+}
+
+// EdgeKind classifies a relationship between two types in a diagram.
+type EdgeKind string
+
+const (
+	EdgeComposition    EdgeKind = "composition"    // A field refers to the other type
+	EdgeEmbedding      EdgeKind = "embedding"      // The other type is embedded
+	EdgeImplementation EdgeKind = "implementation" // The struct implements the interface
+)
+
+type diagramNode struct {
+	pkg         string // Import path, or name when the path is unknown
+	pkgName     string
+	name        string
+	isInterface bool
+}
+
+func (n diagramNode) id() string {
+	return n.pkg + "." + n.name
+}
+
+type diagramEdge struct {
+	from, to string
+	kind     EdgeKind
+	label    string
+}
+
+// GenerateDiagram produces a class diagram of the parsed structs and interfaces showing
+// field composition, embedding and interface implementation, grouped by package.
+func GenerateDiagram(out *Output, opts DiagramOptions) ([]byte, error) {
+	if opts.Format == "" {
+		opts.Format = DiagramMermaid
+	}
+	if opts.Format != DiagramDOT && opts.Format != DiagramMermaid {
+		return nil, fmt.Errorf("unknown diagram format %q", opts.Format)
+	}
+
+	nodes := map[string]diagramNode{}
+	for _, pkg := range out.Packages {
+		for _, s := range pkg.Structs {
+			n := diagramNode{pkg: entityPackage(pkg), pkgName: pkg.Package, name: s.Name}
+			nodes[n.id()] = n
+		}
+		for _, i := range pkg.Interfaces {
+			n := diagramNode{pkg: entityPackage(pkg), pkgName: pkg.Package, name: i.Name, isInterface: true}
+			nodes[n.id()] = n
+		}
+	}
+
+	types := newTypeTable(out)
+	edges := []diagramEdge{}
+	for _, pkg := range out.Packages {
+		for _, s := range pkg.Structs {
+			from := entityPackage(pkg) + "." + s.Name
+			for _, f := range s.Fields {
+				for _, ref := range typeReferences(f.Type) {
+					to := entityPackage(pkg) + "." + ref
+					if qualifier, name, ok := strings.Cut(ref, "."); ok {
+						to = importPathOf(pkg, qualifier) + "." + name
+					}
+					if _, ok := nodes[to]; !ok {
+						continue
+					}
+					if f.Name == "" {
+						edges = append(edges, diagramEdge{from: from, to: to, kind: EdgeEmbedding})
+					} else {
+						edges = append(edges, diagramEdge{from: from, to: to, kind: EdgeComposition, label: f.Name})
+					}
+				}
+			}
+			for _, ipkg := range out.Packages {
+				for _, i := range ipkg.Interfaces {
+					if types.hasMethods(ipkg, i) && types.implements(pkg, s, ipkg, i) {
+						edges = append(edges, diagramEdge{from: from, to: entityPackage(ipkg) + "." + i.Name, kind: EdgeImplementation})
+					}
+				}
+			}
+		}
+	}
+
+	if opts.Root != "" {
+		root := opts.Root
+		if _, ok := nodes[root]; !ok {
+			root = ""
+			for id, n := range nodes {
+				if (n.name == opts.Root || n.pkgName+"."+n.name == opts.Root) && (root == "" || id < root) {
+					root = id
+				}
+			}
+			if root == "" {
+				return nil, fmt.Errorf("root type %q not found", opts.Root)
+			}
+		}
+		nodes, edges = reachable(nodes, edges, root, opts.Depth)
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		if a.from != b.from {
+			return a.from < b.from
+		}
+		if a.to != b.to {
+			return a.to < b.to
+		}
+		return a.label < b.label
+	})
+
+	if opts.Format == DiagramDOT {
+		return writeDOT(nodes, edges), nil
+	}
+	return writeMermaid(nodes, edges), nil
+}
+
+// typeReferences returns the type names referenced by a type string, e.g.
+// "map[string][]*other.Struct" gives ["other.Struct"]. Predeclared types are omitted.
+func typeReferences(typeString string) []string {
+	refs := []string{}
+	for _, ident := range typeIdentifier.FindAllString(typeString, -1) {
+		switch ident {
+		case "map", "chan", "func", "struct", "interface":
+			continue
+		}
+		if _, ok := protoScalars[ident]; ok || ident == "error" || ident == "any" || ident == "complex64" || ident == "complex128" {
+			continue
+		}
+		refs = append(refs, ident)
+	}
+	return refs
+}
+
+// implementsDeclared reports whether the struct s of package spkg has methods (including
+// pointer receiver methods) covering every method of the interface i of package ipkg with
+// matching parameter and return types. Type names are qualified by their package before
+// comparing, so a User of one package does not match a User of another. It is the fallback
+// for Output without method sets; Type.Implements also accounts for promoted methods and
+// embedded interfaces.
+func implementsDeclared(spkg Package, s Struct, ipkg Package, i Interface) bool {
+	methods := make(map[string]string, len(s.Methods))
+	for _, m := range s.Methods {
+		methods[m.Name] = qualifyType(spkg, funcTypeString(m.Params, m.Returns))
+	}
+	for _, m := range i.Methods {
+		if sig, ok := methods[m.Name]; !ok || sig != qualifyType(ipkg, funcTypeString(m.Params, m.Returns)) {
+			return false
+		}
+	}
+	return true
+}
+
+// reachable keeps the nodes and edges within depth edges of root, following edges in
+// both directions of implementation so interfaces show their implementors.
+func reachable(nodes map[string]diagramNode, edges []diagramEdge, root string, depth int) (map[string]diagramNode, []diagramEdge) {
+	adjacent := map[string][]string{}
+	for _, e := range edges {
+		adjacent[e.from] = append(adjacent[e.from], e.to)
+		if e.kind == EdgeImplementation {
+			adjacent[e.to] = append(adjacent[e.to], e.from)
+		}
+	}
+
+	distance := map[string]int{root: 0}
+	queue := []string{root}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		if depth > 0 && distance[id] >= depth {
+			continue
+		}
+		for _, next := range adjacent[id] {
+			if _, seen := distance[next]; !seen {
+				distance[next] = distance[id] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	keptNodes := map[string]diagramNode{}
+	for id := range distance {
+		keptNodes[id] = nodes[id]
+	}
+	keptEdges := []diagramEdge{}
+	for _, e := range edges {
+		_, from := distance[e.from]
+		_, to := distance[e.to]
+		if from && to {
+			keptEdges = append(keptEdges, e)
+		}
+	}
+	return keptNodes, keptEdges
+}
+
+// nodesByPackage groups node ids by package, both sorted.
+func nodesByPackage(nodes map[string]diagramNode) ([]string, map[string][]diagramNode) {
+	byPkg := map[string][]diagramNode{}
+	for _, n := range nodes {
+		byPkg[n.pkg] = append(byPkg[n.pkg], n)
+	}
+	pkgs := make([]string, 0, len(byPkg))
+	for pkg, list := range byPkg {
+		pkgs = append(pkgs, pkg)
+		sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	}
+	sort.Strings(pkgs)
+	return pkgs, byPkg
+}
+
+func writeDOT(nodes map[string]diagramNode, edges []diagramEdge) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("digraph types {\n  rankdir=LR;\n  node [shape=box];\n")
+	pkgs, byPkg := nodesByPackage(nodes)
+	for _, pkg := range pkgs {
+		fmt.Fprintf(&buf, "  subgraph %q {\n    label=%q;\n", "cluster_"+pkg, byPkg[pkg][0].pkgName)
+		for _, n := range byPkg[pkg] {
+			if n.isInterface {
+				fmt.Fprintf(&buf, "    %q [label=%q, style=dashed];\n", n.id(), "«interface»\n"+n.name)
+			} else {
+				fmt.Fprintf(&buf, "    %q [label=%q];\n", n.id(), n.name)
+			}
+		}
+		buf.WriteString("  }\n")
+	}
+	for _, e := range edges {
+		switch e.kind {
+		case EdgeComposition:
+			fmt.Fprintf(&buf, "  %q -> %q [label=%q, arrowtail=diamond, dir=both];\n", e.from, e.to, e.label)
+		case EdgeEmbedding:
+			fmt.Fprintf(&buf, "  %q -> %q [label=\"embeds\", arrowhead=empty];\n", e.from, e.to)
+		case EdgeImplementation:
+			fmt.Fprintf(&buf, "  %q -> %q [style=dashed, arrowhead=empty];\n", e.from, e.to)
+		}
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes()
+}
+
+func writeMermaid(nodes map[string]diagramNode, edges []diagramEdge) []byte {
+	// Mermaid class ids and namespaces are limited to letters, digits and underscores
+	id := func(qualified string) string {
+		return strings.Map(func(r rune) rune {
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '_'
+		}, qualified)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("classDiagram\n")
+	pkgs, byPkg := nodesByPackage(nodes)
+	for _, pkg := range pkgs {
+		fmt.Fprintf(&buf, "  namespace %s {\n", id(pkg))
+		for _, n := range byPkg[pkg] {
+			fmt.Fprintf(&buf, "    class %s[\"%s\"]\n", id(n.id()), n.name)
+		}
+		buf.WriteString("  }\n")
+	}
+	for _, pkg := range pkgs {
+		for _, n := range byPkg[pkg] {
+			if n.isInterface {
+				fmt.Fprintf(&buf, "  <<interface>> %s\n", id(n.id()))
+			}
+		}
+	}
+	for _, e := range edges {
+		switch e.kind {
+		case EdgeComposition:
+			fmt.Fprintf(&buf, "  %s *-- %s : %s\n", id(e.from), id(e.to), e.label)
+		case EdgeEmbedding:
+			fmt.Fprintf(&buf, "  %s <|-- %s : embeds\n", id(e.to), id(e.from))
+		case EdgeImplementation:
+			fmt.Fprintf(&buf, "  %s ..|> %s\n", id(e.from), id(e.to))
+		}
+	}
+	return buf.Bytes()
+}
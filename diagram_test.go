@@ -0,0 +1,121 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDiagram(t *testing.T) {
+	code := `
+	package shop
+
+	type Named interface {
+		Name(prefix string) string
+	}
+
+	type Base struct {
+		ID int
+	}
+
+	type Order struct {
+		Base
+		Customer *Customer
+		Items    []Item
+	}
+
+	type Item struct {
+		SKU string
+	}
+
+	type Customer struct {
+		Address other.Address
+	}
+
+	func (c *Customer) Name(p string) string { return p }
+	`
+	output, err := ParseString(code)
+	require.NoError(t, err)
+
+	t.Run("Mermaid", func(t *testing.T) {
+		diagram, err := GenerateDiagram(output, DiagramOptions{})
+		require.NoError(t, err)
+		require.Equal(t, `classDiagram
+  namespace shop {
+    class shop_Base["Base"]
+    class shop_Customer["Customer"]
+    class shop_Item["Item"]
+    class shop_Named["Named"]
+    class shop_Order["Order"]
+  }
+  <<interface>> shop_Named
+  shop_Customer ..|> shop_Named
+  shop_Base <|-- shop_Order : embeds
+  shop_Order *-- shop_Customer : Customer
+  shop_Order *-- shop_Item : Items
+`, string(diagram))
+	})
+
+	t.Run("DOT", func(t *testing.T) {
+		diagram, err := GenerateDiagram(output, DiagramOptions{Format: DiagramDOT})
+		require.NoError(t, err)
+		require.Contains(t, string(diagram), "  subgraph \"cluster_shop\" {\n    label=\"shop\";\n")
+		require.Contains(t, string(diagram), `"shop.Order" -> "shop.Customer" [label="Customer", arrowtail=diamond, dir=both];`)
+		require.Contains(t, string(diagram), `"shop.Order" -> "shop.Base" [label="embeds", arrowhead=empty];`)
+		require.Contains(t, string(diagram), `"shop.Customer" -> "shop.Named" [style=dashed, arrowhead=empty];`)
+	})
+
+	t.Run("Depth", func(t *testing.T) {
+		diagram, err := GenerateDiagram(output, DiagramOptions{Root: "Order", Depth: 1})
+		require.NoError(t, err)
+		require.Contains(t, string(diagram), "shop_Customer")
+		require.NotContains(t, string(diagram), "shop_Named")
+
+		diagram, err = GenerateDiagram(output, DiagramOptions{Root: "shop.Order", Depth: 2})
+		require.NoError(t, err)
+		require.Contains(t, string(diagram), "shop_Customer ..|> shop_Named")
+
+		_, err = GenerateDiagram(output, DiagramOptions{Root: "Missing"})
+		require.Error(t, err)
+	})
+}
+
+func TestGenerateDiagramPackagePaths(t *testing.T) {
+	// Two packages called models are told apart by import path
+	out := &Output{Packages: []Package{
+		{
+			Package: "api",
+			Path:    "example.com/app/api",
+			Imports: []string{"example.com/app/models"},
+			Structs: []Struct{{Name: "Handler", Fields: []Field{{Name: "User", Type: "*models.User"}}}},
+		},
+		{Package: "models", Path: "example.com/app/models", Structs: []Struct{{Name: "User"}}},
+		{Package: "models", Path: "example.com/legacy/models", Structs: []Struct{{Name: "User"}}},
+	}}
+
+	diagram, err := GenerateDiagram(out, DiagramOptions{Format: DiagramDOT, Root: "api.Handler"})
+	require.NoError(t, err)
+	require.Contains(t, string(diagram), "  subgraph \"cluster_example.com/app/models\" {\n    label=\"models\";\n")
+	require.Contains(t, string(diagram), `"example.com/app/api.Handler" -> "example.com/app/models.User" [label="User", arrowtail=diamond, dir=both];`)
+	require.NotContains(t, string(diagram), "legacy")
+
+	diagram, err = GenerateDiagram(out, DiagramOptions{})
+	require.NoError(t, err)
+	require.Contains(t, string(diagram), "  namespace example_com_legacy_models {\n    class example_com_legacy_models_User[\"User\"]\n")
+	require.Contains(t, string(diagram), "  example_com_app_api_Handler *-- example_com_app_models_User : User\n")
+}
+
+func TestImplementsAcrossPackages(t *testing.T) {
+	save := func(typ string) []Method {
+		return []Method{{Name: "Save", Params: []Param{{Type: typ}}, Returns: []Param{{Type: "error"}}}}
+	}
+	a := Package{Package: "a", Path: "example.com/a", Imports: []string{"example.com/b"}}
+	b := Package{Package: "b", Path: "example.com/b"}
+	store := Struct{Name: "Store", Methods: save("User")}
+
+	// a.User and b.User are different types
+	require.False(t, implementsDeclared(b, store, a, Interface{Name: "Saver", Methods: save("User")}))
+	require.True(t, implementsDeclared(b, store, a, Interface{Name: "Saver", Methods: save("b.User")}))
+	require.True(t, implementsDeclared(b, store, b, Interface{Name: "Saver", Methods: save("User")}))
+	require.False(t, implementsDeclared(b, store, a, Interface{Name: "Saver", Methods: save("*b.User")}))
+}
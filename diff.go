@@ -0,0 +1,361 @@
+package structparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"io"
+	"sort"
+	"strings"
+)
+
+// ChangeKind identifies what changed between two snapshots.
+type ChangeKind string
+
+const (
+	PackageAdded           ChangeKind = "package-added"
+	PackageRemoved         ChangeKind = "package-removed"
+	StructAdded            ChangeKind = "struct-added"
+	StructRemoved          ChangeKind = "struct-removed"
+	FieldAdded             ChangeKind = "field-added"
+	FieldRemoved           ChangeKind = "field-removed"
+	FieldTypeChanged       ChangeKind = "field-type-changed"
+	FieldTagChanged        ChangeKind = "field-tag-changed"
+	MethodAdded            ChangeKind = "method-added"
+	MethodRemoved          ChangeKind = "method-removed"
+	MethodChanged          ChangeKind = "method-changed"
+	InterfaceAdded         ChangeKind = "interface-added"
+	InterfaceRemoved       ChangeKind = "interface-removed"
+	InterfaceMethodAdded   ChangeKind = "interface-method-added"
+	InterfaceMethodRemoved ChangeKind = "interface-method-removed"
+	InterfaceMethodChanged ChangeKind = "interface-method-changed"
+	FunctionAdded          ChangeKind = "function-added"
+	FunctionRemoved        ChangeKind = "function-removed"
+	FunctionChanged        ChangeKind = "function-changed"
+)
+
+// breakingKinds are the changes that can stop code using the old API from compiling.
+var breakingKinds = map[ChangeKind]bool{
+	PackageRemoved:         true,
+	StructRemoved:          true,
+	FieldRemoved:           true,
+	FieldTypeChanged:       true,
+	MethodRemoved:          true,
+	MethodChanged:          true,
+	InterfaceRemoved:       true,
+	InterfaceMethodAdded:   true, // Existing implementations no longer satisfy it
+	InterfaceMethodRemoved: true, // Callers of the method no longer compile
+	InterfaceMethodChanged: true,
+	FunctionRemoved:        true,
+	FunctionChanged:        true,
+}
+
+// Change is a single difference in the exported API of a package.
+type Change struct {
+	Kind     ChangeKind `json:"kind"`
+	Breaking bool       `json:"breaking"`
+	Package  string     `json:"package"`       // Import path, or package name when the path is unknown
+	Object   string     `json:"object"`        // Qualified object name (e.g., "User.Name", "Store.Get")
+	Old      string     `json:"old,omitempty"` // Previous type or signature
+	New      string     `json:"new,omitempty"` // New type or signature
+	Message  string     `json:"message"`       // Human readable description
+}
+
+// Diff compares the exported structs, interfaces and functions of two snapshots. Packages
+// are matched by import path, or by name when the path is unknown. Changes are sorted by
+// package, then object, then kind.
+func Diff(before, after *Output) []Change {
+	d := &differ{}
+	oldPkgs, newPkgs := diffPackages(before), diffPackages(after)
+	for _, key := range unionKeys(oldPkgs, newPkgs) {
+		oldPkg, inOld := oldPkgs[key]
+		newPkg, inNew := newPkgs[key]
+		d.pkg = key
+		switch {
+		case !inNew:
+			d.add(PackageRemoved, key, "", "", "package %s removed", key)
+		case !inOld:
+			d.add(PackageAdded, key, "", "", "package %s added", key)
+		default:
+			d.structs(oldPkg, newPkg)
+			d.interfaces(oldPkg, newPkg)
+			d.functions(oldPkg, newPkg)
+		}
+	}
+	sort.SliceStable(d.changes, func(i, j int) bool {
+		a, b := d.changes[i], d.changes[j]
+		if a.Package != b.Package {
+			return a.Package < b.Package
+		}
+		if a.Object != b.Object {
+			return a.Object < b.Object
+		}
+		return a.Kind < b.Kind
+	})
+	return d.changes
+}
+
+// HasBreakingChanges reports whether any of the changes is breaking.
+func HasBreakingChanges(changes []Change) bool {
+	for _, c := range changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteChangeReport writes changes as a plain text report, breaking changes first.
+func WriteChangeReport(w io.Writer, changes []Change) error {
+	var buf bytes.Buffer
+	if len(changes) == 0 {
+		buf.WriteString("No API changes.\n")
+		_, err := w.Write(buf.Bytes())
+		return err
+	}
+	for _, section := range []struct {
+		title    string
+		breaking bool
+	}{{"Breaking changes", true}, {"Compatible changes", false}} {
+		var lines []string
+		for _, c := range changes {
+			if c.Breaking == section.breaking {
+				lines = append(lines, fmt.Sprintf("  %s: %s\n", c.Package, c.Message))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "%s (%d):\n", section.title, len(lines))
+		buf.WriteString(strings.Join(lines, ""))
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+type differ struct {
+	pkg     string
+	changes []Change
+}
+
+func (d *differ) add(kind ChangeKind, object, old, new, format string, args ...interface{}) {
+	d.changes = append(d.changes, Change{
+		Kind:     kind,
+		Breaking: breakingKinds[kind],
+		Package:  d.pkg,
+		Object:   object,
+		Old:      old,
+		New:      new,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func diffPackages(out *Output) map[string]Package {
+	pkgs := map[string]Package{}
+	if out == nil {
+		return pkgs
+	}
+	for _, pkg := range out.Packages {
+		key := pkg.Path
+		if key == "" {
+			key = pkg.Package
+		}
+		pkgs[key] = pkg
+	}
+	return pkgs
+}
+
+func (d *differ) structs(oldPkg, newPkg Package) {
+	oldStructs, newStructs := map[string]Struct{}, map[string]Struct{}
+	for _, s := range oldPkg.Structs {
+		if ast.IsExported(s.Name) {
+			oldStructs[s.Name] = s
+		}
+	}
+	for _, s := range newPkg.Structs {
+		if ast.IsExported(s.Name) {
+			newStructs[s.Name] = s
+		}
+	}
+	for _, name := range unionKeys(oldStructs, newStructs) {
+		oldStruct, inOld := oldStructs[name]
+		newStruct, inNew := newStructs[name]
+		switch {
+		case !inNew:
+			d.add(StructRemoved, name, "", "", "struct %s removed", name)
+		case !inOld:
+			d.add(StructAdded, name, "", "", "struct %s added", name)
+		default:
+			d.fields(oldStruct, newStruct)
+			d.methods(name, exportedMethods(oldStruct.Methods), exportedMethods(newStruct.Methods), false)
+		}
+	}
+}
+
+func (d *differ) fields(oldStruct, newStruct Struct) {
+	oldFields, newFields := exportedFields(oldStruct), exportedFields(newStruct)
+	for _, name := range unionKeys(oldFields, newFields) {
+		oldField, inOld := oldFields[name]
+		newField, inNew := newFields[name]
+		object := oldStruct.Name + "." + name
+		switch {
+		case !inNew:
+			d.add(FieldRemoved, object, oldField.Type, "", "field %s removed", object)
+		case !inOld:
+			d.add(FieldAdded, object, "", newField.Type, "field %s added", object)
+		case oldField.Type != newField.Type:
+			d.add(FieldTypeChanged, object, oldField.Type, newField.Type, "field %s changed type from %s to %s", object, oldField.Type, newField.Type)
+		case oldField.Tag != newField.Tag:
+			d.add(FieldTagChanged, object, oldField.Tag, newField.Tag, "field %s changed tag from `%s` to `%s`", object, oldField.Tag, newField.Tag)
+		}
+	}
+}
+
+// exportedFields indexes the exported fields of s by name, embedded fields by type name.
+func exportedFields(s Struct) map[string]Field {
+	fields := map[string]Field{}
+	for _, f := range s.Fields {
+		name := f.Name
+		if name == "" {
+			name = embeddedTypeName(f)
+		}
+		if ast.IsExported(name) {
+			fields[name] = f
+		}
+	}
+	return fields
+}
+
+// methods compares methods by their signatures; inInterface selects the interface change
+// kinds.
+func (d *differ) methods(owner string, oldSigs, newSigs map[string]string, inInterface bool) {
+	added, removed, changed := MethodAdded, MethodRemoved, MethodChanged
+	if inInterface {
+		added, removed, changed = InterfaceMethodAdded, InterfaceMethodRemoved, InterfaceMethodChanged
+	}
+	for _, name := range unionKeys(oldSigs, newSigs) {
+		oldSig, inOld := oldSigs[name]
+		newSig, inNew := newSigs[name]
+		object := owner + "." + name
+		switch {
+		case !inNew:
+			d.add(removed, object, oldSig, "", "method %s removed", object)
+		case !inOld:
+			d.add(added, object, "", newSig, "method %s added", object)
+		case oldSig != newSig:
+			d.add(changed, object, oldSig, newSig, "method %s changed from %s to %s", object, oldSig, newSig)
+		}
+	}
+}
+
+// exportedMethods returns the signatures of the exported methods by name.
+func exportedMethods(methods []Method) map[string]string {
+	sigs := map[string]string{}
+	for _, m := range methods {
+		if ast.IsExported(m.Name) {
+			sigs[m.Name] = methodSignature(m)
+		}
+	}
+	return sigs
+}
+
+// interfaceMethods returns the signatures of the exported methods of the interface i of
+// pkg by name, including those of embedded interfaces. Output without method sets, such
+// as decoded from an older version, only has the methods the interface declares.
+func interfaceMethods(pkg Package, i Interface) map[string]string {
+	for _, t := range pkg.Types {
+		if t.Name != i.Name || t.Kind != "interface" {
+			continue
+		}
+		sigs := map[string]string{}
+		for _, m := range t.MethodSet {
+			if ast.IsExported(m.Name) {
+				sigs[m.Name] = m.Signature
+			}
+		}
+		return sigs
+	}
+	return exportedMethods(i.Methods)
+}
+
+// methodSignature describes a method by receiver, parameter and result types, ignoring
+// parameter names.
+func methodSignature(m Method) string {
+	sig := funcTypeString(m.Params, m.Returns)
+	if m.Receiver != "" {
+		sig = "(" + m.Receiver + ") " + sig
+	}
+	return sig
+}
+
+func (d *differ) interfaces(oldPkg, newPkg Package) {
+	oldIfaces, newIfaces := map[string]Interface{}, map[string]Interface{}
+	for _, i := range oldPkg.Interfaces {
+		if ast.IsExported(i.Name) {
+			oldIfaces[i.Name] = i
+		}
+	}
+	for _, i := range newPkg.Interfaces {
+		if ast.IsExported(i.Name) {
+			newIfaces[i.Name] = i
+		}
+	}
+	for _, name := range unionKeys(oldIfaces, newIfaces) {
+		oldIface, inOld := oldIfaces[name]
+		newIface, inNew := newIfaces[name]
+		switch {
+		case !inNew:
+			d.add(InterfaceRemoved, name, "", "", "interface %s removed", name)
+		case !inOld:
+			d.add(InterfaceAdded, name, "", "", "interface %s added", name)
+		default:
+			d.methods(name, interfaceMethods(oldPkg, oldIface), interfaceMethods(newPkg, newIface), true)
+		}
+	}
+}
+
+func (d *differ) functions(oldPkg, newPkg Package) {
+	oldFuncs, newFuncs := map[string]Function{}, map[string]Function{}
+	for _, f := range oldPkg.Functions {
+		if ast.IsExported(f.Name) {
+			oldFuncs[f.Name] = f
+		}
+	}
+	for _, f := range newPkg.Functions {
+		if ast.IsExported(f.Name) {
+			newFuncs[f.Name] = f
+		}
+	}
+	for _, name := range unionKeys(oldFuncs, newFuncs) {
+		oldFunc, inOld := oldFuncs[name]
+		newFunc, inNew := newFuncs[name]
+		oldSig, newSig := funcTypeString(oldFunc.Params, oldFunc.Returns), funcTypeString(newFunc.Params, newFunc.Returns)
+		switch {
+		case !inNew:
+			d.add(FunctionRemoved, name, oldSig, "", "function %s removed", name)
+		case !inOld:
+			d.add(FunctionAdded, name, "", newSig, "function %s added", name)
+		case oldSig != newSig:
+			d.add(FunctionChanged, name, oldSig, newSig, "function %s changed from %s to %s", name, oldSig, newSig)
+		}
+	}
+}
+
+// unionKeys returns the keys present in either map, sorted.
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, m := range []map[string]V{a, b} {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
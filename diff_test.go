@@ -0,0 +1,146 @@
+package structparser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	before, err := ParseString(`
+package store
+
+type User struct {
+	ID    int
+	Name  string ` + "`json:\"name\"`" + `
+	Email string
+	note  string
+}
+
+func (u *User) Display() string { return u.Name }
+
+type Store interface {
+	Get(id int) (*User, error)
+}
+
+type Legacy struct{}
+
+func Open(path string) (Store, error) { return nil, nil }
+
+func Lookup(string) error { return nil }
+`)
+	require.NoError(t, err)
+	after, err := ParseString(`
+package store
+
+type User struct {
+	ID    int64
+	Name  string ` + "`json:\"full_name\"`" + `
+	Phone string
+	other string
+}
+
+func (u *User) Display(short bool) string { return u.Name }
+
+type Store interface {
+	Get(key int) (*User, error)
+	Put(u *User) error
+}
+
+type Admin struct{}
+
+func Open(path string) (Store, error) { return nil, nil }
+
+func Lookup(int) error { return nil }
+`)
+	require.NoError(t, err)
+
+	changes := Diff(before, after)
+	summary := map[string]ChangeKind{}
+	for _, c := range changes {
+		require.Equal(t, "store", c.Package)
+		require.Equal(t, breakingKinds[c.Kind], c.Breaking)
+		summary[c.Object] = c.Kind
+	}
+	require.Equal(t, map[string]ChangeKind{
+		"Admin":        StructAdded,
+		"Legacy":       StructRemoved,
+		"Lookup":       FunctionChanged,
+		"Store.Put":    InterfaceMethodAdded,
+		"User.Display": MethodChanged,
+		"User.Email":   FieldRemoved,
+		"User.ID":      FieldTypeChanged,
+		"User.Name":    FieldTagChanged,
+		"User.Phone":   FieldAdded,
+	}, summary)
+	require.True(t, HasBreakingChanges(changes))
+
+	for _, c := range changes {
+		if c.Object == "User.Display" {
+			require.Equal(t, "(*User) func() string", c.Old)
+			require.Equal(t, "(*User) func(bool) string", c.New)
+		}
+		if c.Object == "Lookup" {
+			require.Equal(t, "func(string) error", c.Old)
+			require.Equal(t, "func(int) error", c.New)
+		}
+	}
+
+	require.Empty(t, Diff(before, before))
+	require.False(t, HasBreakingChanges(Diff(before, before)))
+}
+
+func TestDiffEmbeddedInterfaces(t *testing.T) {
+	before, err := ParseString(`
+package store
+
+type Closer interface {
+	Close() error
+}
+
+type Store interface {
+	Get(id int) error
+}
+`)
+	require.NoError(t, err)
+	after, err := ParseString(`
+package store
+
+type Closer interface {
+	Close() error
+}
+
+type Store interface {
+	Closer
+	Get(id int) error
+}
+`)
+	require.NoError(t, err)
+
+	// Embedding Closer adds Close to Store
+	changes := Diff(before, after)
+	require.Len(t, changes, 1)
+	require.Equal(t, InterfaceMethodAdded, changes[0].Kind)
+	require.Equal(t, "Store.Close", changes[0].Object)
+	require.Equal(t, "func() error", changes[0].New)
+	require.True(t, changes[0].Breaking)
+}
+
+func TestWriteChangeReport(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteChangeReport(&buf, []Change{
+		{Kind: FieldRemoved, Breaking: true, Package: "store", Object: "User.Email", Message: "field User.Email removed"},
+		{Kind: FieldAdded, Package: "store", Object: "User.Phone", Message: "field User.Phone added"},
+	}))
+	require.Equal(t, `Breaking changes (1):
+  store: field User.Email removed
+
+Compatible changes (1):
+  store: field User.Phone added
+`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, WriteChangeReport(&buf, nil))
+	require.Equal(t, "No API changes.\n", buf.String())
+}
@@ -0,0 +1,487 @@
+package structparser
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// DocsFormat selects the page format produced by GenerateDocs.
+type DocsFormat string
+
+const (
+	DocsMarkdown DocsFormat = "markdown"
+	DocsHTML     DocsFormat = "html"
+)
+
+// DocsOptions configures GenerateDocs.
+type DocsOptions struct {
+	// Format defaults to DocsMarkdown.
+	Format DocsFormat
+	// PageName returns the file name of the page of the package with the given import path,
+	// or package name for packages outside a module. Defaults to "<package>.md" or
+	// "<package>.html" depending on Format, using the import path with slashes replaced by
+	// underscores for packages whose name is shared with another package.
+	PageName func(path string) string
+}
+
+// GenerateDocs renders one reference page per package, keyed by page name. Every entity
+// gets an anchor ("struct-Name", "method-Type-Name", ...) and type names in signatures and
+// field tables link to the entity that declares them, across packages.
+func GenerateDocs(out *Output, opts DocsOptions) (map[string][]byte, error) {
+	if opts.Format == "" {
+		opts.Format = DocsMarkdown
+	}
+	ext := map[DocsFormat]string{DocsMarkdown: ".md", DocsHTML: ".html"}[opts.Format]
+	if ext == "" {
+		return nil, fmt.Errorf("unknown docs format %q", opts.Format)
+	}
+	if opts.PageName == nil {
+		names := map[string]int{}
+		packageNames := map[string]string{}
+		for _, pkg := range out.Packages {
+			names[pkg.Package]++
+			packageNames[entityPackage(pkg)] = pkg.Package
+		}
+		opts.PageName = func(path string) string {
+			if name, ok := packageNames[path]; ok && names[name] == 1 {
+				return name + ext
+			}
+			return strings.ReplaceAll(path, "/", "_") + ext
+		}
+	}
+
+	// Anchors of every linkable type, by import path
+	anchors := map[string]map[string]string{}
+	for _, pkg := range out.Packages {
+		a := map[string]string{}
+		for _, s := range pkg.Structs {
+			a[s.Name] = docsAnchor("struct", s.Name)
+		}
+		for _, i := range pkg.Interfaces {
+			a[i.Name] = docsAnchor("interface", i.Name)
+		}
+		for _, e := range pkg.Enums {
+			a[e.Name] = docsAnchor("enum", e.Name)
+		}
+		anchors[entityPackage(pkg)] = a
+	}
+
+	pages := map[string][]byte{}
+	pagePackages := map[string]string{}
+	for i := range out.Packages {
+		pkg := &out.Packages[i]
+		name := opts.PageName(entityPackage(*pkg))
+		if other, ok := pagePackages[name]; ok {
+			return nil, fmt.Errorf("packages %s and %s have the same page name %s", other, entityPackage(*pkg), name)
+		}
+		pagePackages[name] = entityPackage(*pkg)
+		r := &docsRenderer{out: out, pkg: pkg, anchors: anchors, pageName: opts.PageName}
+		var (
+			page []byte
+			err  error
+		)
+		if opts.Format == DocsHTML {
+			page, err = r.html()
+		} else {
+			page = r.markdown()
+		}
+		if err != nil {
+			return nil, err
+		}
+		pages[name] = page
+	}
+	return pages, nil
+}
+
+func docsAnchor(kind string, names ...string) string {
+	return kind + "-" + strings.Join(names, "-")
+}
+
+type docsRenderer struct {
+	out      *Output
+	pkg      *Package
+	anchors  map[string]map[string]string // Anchors by import path and type name
+	pageName func(path string) string
+}
+
+// typeIdentifier matches type names, optionally package qualified, inside a type string.
+var typeIdentifier = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?`)
+
+// typeSegment is a piece of a type string, linked when it names a documented type.
+type typeSegment struct {
+	Text string
+	Link string
+}
+
+func (r *docsRenderer) typeSegments(typeString string) []typeSegment {
+	return r.segments(typeString, nil)
+}
+
+// signatureSegments links the type names of a signature such as
+// "Get(id int) (*User, error)". The function name and parameter names, which are
+// followed by a space, are left as they are.
+func (r *docsRenderer) signatureSegments(signature string) []typeSegment {
+	return r.segments(signature, func(start, end int) bool {
+		return start == 0 || end < len(signature) && signature[end] == ' '
+	})
+}
+
+// segments splits s into segments, linking the identifiers that name a documented type
+// unless skip reports they are something else.
+func (r *docsRenderer) segments(typeString string, skip func(start, end int) bool) []typeSegment {
+	segments := []typeSegment{}
+	last := 0
+	for _, m := range typeIdentifier.FindAllStringIndex(typeString, -1) {
+		if skip != nil && skip(m[0], m[1]) {
+			continue
+		}
+		ident := typeString[m[0]:m[1]]
+		link := ""
+		if qualifier, name, ok := strings.Cut(ident, "."); ok {
+			if pkg, ok := importedPackage(r.out, *r.pkg, qualifier); ok {
+				if anchor, ok := r.anchors[entityPackage(pkg)][name]; ok {
+					link = r.pageName(entityPackage(pkg)) + "#" + anchor
+				}
+			}
+		} else if anchor, ok := r.anchors[entityPackage(*r.pkg)][ident]; ok {
+			link = "#" + anchor
+		}
+		if link == "" {
+			continue
+		}
+		if m[0] > last {
+			segments = append(segments, typeSegment{Text: typeString[last:m[0]]})
+		}
+		segments = append(segments, typeSegment{Text: ident, Link: link})
+		last = m[1]
+	}
+	if last < len(typeString) {
+		segments = append(segments, typeSegment{Text: typeString[last:]})
+	}
+	return segments
+}
+
+func (r *docsRenderer) markdownType(typeString string) string {
+	var b strings.Builder
+	for _, s := range r.typeSegments(typeString) {
+		if s.Link != "" {
+			fmt.Fprintf(&b, "[%s](%s)", markdownCode(s.Text), s.Link)
+		} else {
+			b.WriteString(markdownCode(s.Text))
+		}
+	}
+	return b.String()
+}
+
+// htmlSegments renders segments as HTML, with links.
+func htmlSegments(segments []typeSegment) string {
+	var b strings.Builder
+	for _, s := range segments {
+		if s.Link != "" {
+			fmt.Fprintf(&b, `<a href="%s">%s</a>`, template.HTMLEscapeString(s.Link), template.HTMLEscapeString(s.Text))
+		} else {
+			b.WriteString(template.HTMLEscapeString(s.Text))
+		}
+	}
+	return b.String()
+}
+
+// markdownSignature renders a signature as a code block. Markdown does not link inside
+// fenced code, so the block is written in HTML.
+func (r *docsRenderer) markdownSignature(signature string) string {
+	return "<pre><code>" + htmlSegments(r.signatureSegments(signature)) + "</code></pre>"
+}
+
+// markdownCode returns s as a Markdown code span, delimited by more backticks than any run
+// of backticks in s.
+func markdownCode(s string) string {
+	longest, run := 0, 0
+	for _, c := range s {
+		if c == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+func (r *docsRenderer) markdown() []byte {
+	var b bytes.Buffer
+	pkg := r.pkg
+	cell := func(s string) string {
+		return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", "<br>")
+	}
+	anchor := func(id string) {
+		fmt.Fprintf(&b, "<a id=%q></a>\n\n", id)
+	}
+	paragraph := func(docs []string) {
+		// Methods without docs come back as a single empty line
+		if text := strings.TrimSpace(strings.Join(docs, "\n")); text != "" {
+			fmt.Fprintf(&b, "%s\n\n", text)
+		}
+	}
+
+	fmt.Fprintf(&b, "# Package %s\n\n", pkg.Package)
+
+	b.WriteString("## Index\n\n")
+	for _, e := range pkg.Enums {
+		fmt.Fprintf(&b, "- [enum %s](#%s)\n", e.Name, docsAnchor("enum", e.Name))
+	}
+	for _, i := range pkg.Interfaces {
+		fmt.Fprintf(&b, "- [interface %s](#%s)\n", i.Name, docsAnchor("interface", i.Name))
+	}
+	for _, s := range pkg.Structs {
+		fmt.Fprintf(&b, "- [struct %s](#%s)\n", s.Name, docsAnchor("struct", s.Name))
+	}
+	for _, f := range pkg.Functions {
+		fmt.Fprintf(&b, "- [func %s](#%s)\n", f.Name, docsAnchor("func", f.Name))
+	}
+	b.WriteString("\n")
+
+	if len(pkg.Constants) > 0 {
+		b.WriteString("## Constants\n\n| Name | Type | Value | Docs |\n| --- | --- | --- | --- |\n")
+		for _, c := range pkg.Constants {
+			typ := ""
+			if c.Type != "" {
+				typ = r.markdownType(c.Type)
+			}
+			fmt.Fprintf(&b, "| <a id=%q></a>`%s` | %s | %s | %s |\n", docsAnchor("const", c.Name), c.Name, typ, cell(markdownCode(c.Value)), cell(strings.Join(c.Docs, "\n")))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(pkg.Variables) > 0 {
+		b.WriteString("## Variables\n\n| Name | Type | Docs |\n| --- | --- | --- |\n")
+		for _, v := range pkg.Variables {
+			typ := ""
+			if v.Type != "" {
+				typ = r.markdownType(v.Type)
+			}
+			fmt.Fprintf(&b, "| <a id=%q></a>`%s` | %s | %s |\n", docsAnchor("var", v.Name), v.Name, typ, cell(strings.Join(v.Docs, "\n")))
+		}
+		b.WriteString("\n")
+	}
+
+	if len(pkg.Enums) > 0 {
+		b.WriteString("## Enums\n\n")
+		for _, e := range pkg.Enums {
+			anchor(docsAnchor("enum", e.Name))
+			fmt.Fprintf(&b, "### %s\n\n", e.Name)
+			fmt.Fprintf(&b, "Underlying type: %s\n\n", markdownCode(e.Type))
+			paragraph(e.Docs)
+			b.WriteString("| Name | Value | Docs |\n| --- | --- | --- |\n")
+			for _, v := range e.Values {
+				fmt.Fprintf(&b, "| `%s` | %s | %s |\n", v.Name, cell(markdownCode(v.Value)), cell(strings.Join(v.Docs, "\n")))
+			}
+			b.WriteString("\n")
+		}
+	}
+
+	if len(pkg.Interfaces) > 0 {
+		b.WriteString("## Interfaces\n\n")
+		for _, i := range pkg.Interfaces {
+			anchor(docsAnchor("interface", i.Name))
+			fmt.Fprintf(&b, "### %s\n\n", i.Name)
+			paragraph(i.Docs)
+			for _, m := range i.Methods {
+				anchor(docsAnchor("method", i.Name, m.Name))
+				fmt.Fprintf(&b, "#### %s.%s\n\n%s\n\n", i.Name, m.Name, r.markdownSignature(m.Signature))
+				paragraph(m.Docs)
+			}
+		}
+	}
+
+	if len(pkg.Structs) > 0 {
+		b.WriteString("## Structs\n\n")
+		for _, s := range pkg.Structs {
+			anchor(docsAnchor("struct", s.Name))
+			fmt.Fprintf(&b, "### %s\n\n", s.Name)
+			paragraph(s.Docs)
+			if len(s.Fields) > 0 {
+				b.WriteString("| Name | Type | Tags | Docs | Comment |\n| --- | --- | --- | --- | --- |\n")
+				for _, f := range s.Fields {
+					name := f.Name
+					if name == "" {
+						name = "*embedded*"
+					} else {
+						name = "`" + name + "`"
+					}
+					tag := ""
+					if f.Tag != "" {
+						tag = cell(markdownCode(f.Tag))
+					}
+					fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", name, r.markdownType(f.Type), tag, cell(strings.Join(f.Docs, "\n")), cell(f.Comment))
+				}
+				b.WriteString("\n")
+			}
+			for _, m := range s.Methods {
+				anchor(docsAnchor("method", s.Name, m.Name))
+				fmt.Fprintf(&b, "#### func (%s) %s\n\n%s\n\n", m.Receiver, m.Name, r.markdownSignature(m.Signature))
+				paragraph(m.Docs)
+			}
+		}
+	}
+
+	if len(pkg.Functions) > 0 {
+		b.WriteString("## Functions\n\n")
+		for _, f := range pkg.Functions {
+			anchor(docsAnchor("func", f.Name))
+			fmt.Fprintf(&b, "### %s\n\n%s\n\n", f.Name, r.markdownSignature(f.Signature))
+			paragraph(f.Docs)
+		}
+	}
+
+	return append(bytes.TrimRight(b.Bytes(), "\n"), '\n')
+}
+
+const docsHTMLTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Package {{.Package}}</title>
+</head>
+<body>
+<h1>Package {{.Package}}</h1>
+<h2>Index</h2>
+<ul>
+{{- range .Enums}}
+<li><a href="#{{anchor "enum" .Name}}">enum {{.Name}}</a></li>
+{{- end}}
+{{- range .Interfaces}}
+<li><a href="#{{anchor "interface" .Name}}">interface {{.Name}}</a></li>
+{{- end}}
+{{- range .Structs}}
+<li><a href="#{{anchor "struct" .Name}}">struct {{.Name}}</a></li>
+{{- end}}
+{{- range .Functions}}
+<li><a href="#{{anchor "func" .Name}}">func {{.Name}}</a></li>
+{{- end}}
+</ul>
+{{- if .Constants}}
+<h2>Constants</h2>
+<table>
+<tr><th>Name</th><th>Type</th><th>Value</th><th>Docs</th></tr>
+{{- range .Constants}}
+<tr id="{{anchor "const" .Name}}"><td><code>{{.Name}}</code></td><td>{{typ .Type}}</td><td><code>{{.Value}}</code></td><td>{{docs .Docs}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Variables}}
+<h2>Variables</h2>
+<table>
+<tr><th>Name</th><th>Type</th><th>Docs</th></tr>
+{{- range .Variables}}
+<tr id="{{anchor "var" .Name}}"><td><code>{{.Name}}</code></td><td>{{typ .Type}}</td><td>{{docs .Docs}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- if .Enums}}
+<h2>Enums</h2>
+{{- range .Enums}}
+<h3 id="{{anchor "enum" .Name}}">{{.Name}}</h3>
+<p>Underlying type: <code>{{.Type}}</code></p>
+{{- with docs .Docs}}
+<p>{{.}}</p>
+{{- end}}
+<table>
+<tr><th>Name</th><th>Value</th><th>Docs</th></tr>
+{{- range .Values}}
+<tr><td><code>{{.Name}}</code></td><td><code>{{.Value}}</code></td><td>{{docs .Docs}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- if .Interfaces}}
+<h2>Interfaces</h2>
+{{- range $i := .Interfaces}}
+<h3 id="{{anchor "interface" .Name}}">{{.Name}}</h3>
+{{- with docs .Docs}}
+<p>{{.}}</p>
+{{- end}}
+{{- range .Methods}}
+<h4 id="{{anchor "method" $i.Name .Name}}">{{$i.Name}}.{{.Name}}</h4>
+<pre><code>{{sig .Signature}}</code></pre>
+{{- with docs .Docs}}
+<p>{{.}}</p>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Structs}}
+<h2>Structs</h2>
+{{- range $s := .Structs}}
+<h3 id="{{anchor "struct" .Name}}">{{.Name}}</h3>
+{{- with docs .Docs}}
+<p>{{.}}</p>
+{{- end}}
+{{- if .Fields}}
+<table>
+<tr><th>Name</th><th>Type</th><th>Tags</th><th>Docs</th><th>Comment</th></tr>
+{{- range .Fields}}
+<tr><td>{{if .Name}}<code>{{.Name}}</code>{{else}}<em>embedded</em>{{end}}</td><td>{{typ .Type}}</td><td>{{if .Tag}}<code>{{.Tag}}</code>{{end}}</td><td>{{docs .Docs}}</td><td>{{.Comment}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- range .Methods}}
+<h4 id="{{anchor "method" $s.Name .Name}}">func ({{.Receiver}}) {{.Name}}</h4>
+<pre><code>{{sig .Signature}}</code></pre>
+{{- with docs .Docs}}
+<p>{{.}}</p>
+{{- end}}
+{{- end}}
+{{- end}}
+{{- end}}
+{{- if .Functions}}
+<h2>Functions</h2>
+{{- range .Functions}}
+<h3 id="{{anchor "func" .Name}}">{{.Name}}</h3>
+<pre><code>{{sig .Signature}}</code></pre>
+{{- with docs .Docs}}
+<p>{{.}}</p>
+{{- end}}
+{{- end}}
+{{- end}}
+</body>
+</html>
+`
+
+func (r *docsRenderer) html() ([]byte, error) {
+	t, err := template.New("package").Funcs(template.FuncMap{
+		"anchor": docsAnchor,
+		"docs": func(docs []string) template.HTML {
+			escaped := make([]string, 0, len(docs))
+			for _, d := range docs {
+				escaped = append(escaped, template.HTMLEscapeString(d))
+			}
+			return template.HTML(strings.Join(escaped, "<br>"))
+		},
+		"typ": func(typeString string) template.HTML {
+			if typeString == "" {
+				return ""
+			}
+			return template.HTML("<code>" + htmlSegments(r.typeSegments(typeString)) + "</code>")
+		},
+		"sig": func(signature string) template.HTML {
+			return template.HTML(htmlSegments(r.signatureSegments(signature)))
+		},
+	}).Parse(docsHTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, r.pkg); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
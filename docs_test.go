@@ -0,0 +1,101 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateDocs(t *testing.T) {
+	tmp, err := ParseDirectory("./example")
+	require.NoError(t, err)
+	other, err := ParseDirectory("./example/other")
+	require.NoError(t, err)
+	tmp.Packages = append(tmp.Packages, other.Packages...)
+
+	t.Run("Markdown", func(t *testing.T) {
+		pages, err := GenerateDocs(tmp, DocsOptions{})
+		require.NoError(t, err)
+		require.Len(t, pages, 2)
+		page := string(pages["structs.md"])
+
+		require.Contains(t, page, "# Package structs\n")
+		require.Contains(t, page, "- [struct FirstStruct](#struct-FirstStruct)\n")
+		require.Contains(t, page, "<a id=\"struct-FirstStruct\"></a>\n\n### FirstStruct\n\nFirstStruct this is the comment for the first struct.\nThis is new line.\n")
+		require.Contains(t, page, "| `SecondStruct` | [`SecondStruct`](#struct-SecondStruct) |  |")
+		require.Contains(t, page, "| `Int` | `int` | `json:\"int\" bson:\"int\"` |")
+		require.Contains(t, page, "| `MapStringPackageStruct` | `map[string]`[`other.Struct`](other.md#struct-Struct) |")
+		require.Contains(t, page, "<a id=\"method-FirstStruct-MyTestMethod\"></a>\n\n#### func (*FirstStruct) MyTestMethod\n\n<pre><code>MyTestMethod(")
+		require.NotContains(t, page, "\n\n\n")
+		require.Contains(t, page, "| <a id=\"const-MyConstant\"></a>`MyConstant` |  | `\"world\"` |  |")
+		require.Contains(t, page, "### MyFunction\n\n<pre><code>MyFunction(arg string) (string, error)</code></pre>\n\nMyFunction is a function\n")
+	})
+
+	t.Run("HTML", func(t *testing.T) {
+		pages, err := GenerateDocs(tmp, DocsOptions{Format: DocsHTML})
+		require.NoError(t, err)
+		page := string(pages["structs.html"])
+
+		require.Contains(t, page, `<h3 id="struct-FirstStruct">FirstStruct</h3>`)
+		require.Contains(t, page, `<td><code>PointerPackageStruct</code></td><td><code>*<a href="other.html#struct-Struct">other.Struct</a></code></td>`)
+		require.Contains(t, page, `<td><code>json:&#34;int&#34; bson:&#34;int&#34;</code></td>`)
+		require.Contains(t, page, `<h4 id="method-FirstStruct-MyTestMethod">func (*FirstStruct) MyTestMethod</h4>`)
+		require.NotContains(t, page, "<p></p>")
+	})
+}
+
+func TestGenerateDocsSignatureLinks(t *testing.T) {
+	output, err := ParseString(`package store
+
+type User struct{}
+
+type Store struct{}
+
+// Get returns a user
+func (s *Store) Get(User int, users map[string]*User) (*User, error) { return nil, nil }
+
+type Getter interface {
+	Get(id int) (*User, error)
+}
+
+func Open(u User) map[string]*Store { return nil }
+`)
+	require.NoError(t, err)
+
+	pages, err := GenerateDocs(output, DocsOptions{})
+	require.NoError(t, err)
+	page := string(pages["store.md"])
+	// Parameter names are not linked, even when they are spelled like a type
+	require.Contains(t, page, "#### func (*Store) Get\n\n<pre><code>Get(User int, users map[string]*<a href=\"#struct-User\">User</a>) (*<a href=\"#struct-User\">User</a>, error)</code></pre>\n\nGet returns a user\n")
+	require.Contains(t, page, "<pre><code>Get(id int) (*<a href=\"#struct-User\">User</a>, error)</code></pre>")
+	require.Contains(t, page, "<pre><code>Open(u <a href=\"#struct-User\">User</a>) (map[string]*<a href=\"#struct-Store\">Store</a>)</code></pre>")
+
+	pages, err = GenerateDocs(output, DocsOptions{Format: DocsHTML})
+	require.NoError(t, err)
+	require.Contains(t, string(pages["store.html"]), "<pre><code>Open(u <a href=\"#struct-User\">User</a>) (map[string]*<a href=\"#struct-Store\">Store</a>)</code></pre>")
+}
+
+func TestGenerateDocsSameName(t *testing.T) {
+	out := &Output{Packages: []Package{
+		{
+			Package:   "models",
+			Path:      "example.com/shop/models",
+			Structs:   []Struct{{Name: "Order"}},
+			Constants: []Constant{{Name: "Quote", Value: "`x`"}},
+		},
+		{
+			Package: "models",
+			Path:    "example.com/billing/models",
+			Imports: []string{"example.com/shop/models"},
+			Structs: []Struct{{Name: "Invoice", Fields: []Field{{Name: "Order", Type: "models.Order"}}}},
+		},
+	}}
+	pages, err := GenerateDocs(out, DocsOptions{})
+	require.NoError(t, err)
+	require.Len(t, pages, 2)
+	require.Contains(t, string(pages["example.com_shop_models.md"]), "| <a id=\"const-Quote\"></a>`Quote` |  | `` `x` `` |")
+	require.Contains(t, string(pages["example.com_billing_models.md"]), "[`models.Order`](example.com_shop_models.md#struct-Order)")
+
+	_, err = GenerateDocs(out, DocsOptions{PageName: func(string) string { return "models.md" }})
+	require.Error(t, err)
+}
@@ -0,0 +1,221 @@
+package structparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Encoding writes Output snapshots in a particular format and reads them back.
+//
+// Every built-in encoding follows the `json` tags of the Output types, so field names
+// and field order are the same whatever the format.
+type Encoding interface {
+	Encode(w io.Writer, out *Output) error
+	Decode(r io.Reader) (*Output, error)
+}
+
+var (
+	encodingsMu sync.RWMutex
+	encodings   = map[string]Encoding{
+		"json":    jsonEncoding{},
+		"yaml":    yamlEncoding{},
+		"toml":    tomlEncoding{},
+		"msgpack": msgpackEncoding{},
+	}
+)
+
+// RegisterEncoding makes an encoding available by name, replacing any encoding
+// registered under the same name.
+func RegisterEncoding(name string, e Encoding) {
+	encodingsMu.Lock()
+	defer encodingsMu.Unlock()
+	encodings[name] = e
+}
+
+// LookupEncoding returns the encoding registered under name.
+func LookupEncoding(name string) (Encoding, bool) {
+	encodingsMu.RLock()
+	defer encodingsMu.RUnlock()
+	e, ok := encodings[name]
+	return e, ok
+}
+
+// Encodings returns the names of the registered encodings, sorted.
+func Encodings() []string {
+	encodingsMu.RLock()
+	defer encodingsMu.RUnlock()
+	names := make([]string, 0, len(encodings))
+	for name := range encodings {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// orderedMap is a JSON object that remembers the order of its keys.
+type orderedMap []orderedEntry
+
+type orderedEntry struct {
+	Key   string
+	Value interface{}
+}
+
+// MarshalJSON writes the object with its keys in order.
+func (m orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, e := range m {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(e.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(e.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// orderedTree converts v into nested orderedMap, []interface{}, string, json.Number, bool
+// and nil values, keeping the field order of its JSON encoding.
+func orderedTree(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return readOrdered(dec)
+}
+
+func readOrdered(dec *json.Decoder) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return tok, nil
+	}
+	switch delim {
+	case '{':
+		m := orderedMap{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := readOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, orderedEntry{Key: key.(string), Value: value})
+		}
+		_, err = dec.Token()
+		return m, err
+	case '[':
+		list := []interface{}{}
+		for dec.More() {
+			value, err := readOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+	return nil, fmt.Errorf("unexpected delimiter %v", delim)
+}
+
+// outputFromTree converts a decoded generic value back into an Output by way of its
+// JSON representation.
+func outputFromTree(tree interface{}) (*Output, error) {
+	raw, err := json.Marshal(tree)
+	if err != nil {
+		return nil, err
+	}
+	out := &Output{}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type jsonEncoding struct{}
+
+func (jsonEncoding) Encode(w io.Writer, out *Output) error {
+	return json.NewEncoder(w).Encode(out)
+}
+
+func (jsonEncoding) Decode(r io.Reader) (*Output, error) {
+	out := &Output{}
+	if err := json.NewDecoder(r).Decode(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type yamlEncoding struct{}
+
+func (yamlEncoding) Encode(w io.Writer, out *Output) error {
+	tree, err := orderedTree(out)
+	if err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(tree)); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func (yamlEncoding) Decode(r io.Reader) (*Output, error) {
+	var tree interface{}
+	if err := yaml.NewDecoder(r).Decode(&tree); err != nil {
+		return nil, err
+	}
+	return outputFromTree(tree)
+}
+
+func yamlNode(v interface{}) *yaml.Node {
+	switch v := v.(type) {
+	case orderedMap:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, e := range v {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: e.Key}, yamlNode(e.Value))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: v.String()}
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: v.String()}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(v)}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
@@ -0,0 +1,116 @@
+package structparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodings(t *testing.T) {
+	require.Equal(t, []string{"json", "msgpack", "toml", "yaml"}, Encodings())
+
+	output, err := ParseDirectory("./example")
+	require.NoError(t, err)
+
+	// Decoding JSON gives the reference, since null and empty slices already collapse
+	raw, err := json.Marshal(output)
+	require.NoError(t, err)
+	expected := &Output{}
+	require.NoError(t, json.Unmarshal(raw, expected))
+
+	for _, name := range Encodings() {
+		t.Run(name, func(t *testing.T) {
+			enc, ok := LookupEncoding(name)
+			require.True(t, ok)
+
+			var buf bytes.Buffer
+			require.NoError(t, enc.Encode(&buf, output))
+			decoded, err := enc.Decode(&buf)
+			require.NoError(t, err)
+			require.Equal(t, expected, decoded)
+		})
+	}
+}
+
+func TestEncodingFieldOrder(t *testing.T) {
+	output, err := ParseString(`
+package orders
+
+// Order is an order
+type Order struct {
+	ID   int     ` + "`json:\"id\"`" + `
+	Note string  // note
+	Cost float64
+}
+`)
+	require.NoError(t, err)
+
+	t.Run("YAML", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, yamlEncoding{}.Encode(&buf, output))
+		text := buf.String()
+		require.Less(t, strings.Index(text, "package: orders"), strings.Index(text, "structs:"))
+		require.Contains(t, text, "          - name: ID\n            type: int\n")
+	})
+
+	t.Run("TOML", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, tomlEncoding{}.Encode(&buf, output))
+		text := buf.String()
+		require.Contains(t, text, "[[packages]]\npackage = \"orders\"\n")
+		require.Contains(t, text, "[[packages.structs.fields]]\nname = \"ID\"\ntype = \"int\"\ntag = \"json:\\\"id\\\"\"\n")
+	})
+}
+
+func TestDecodeTOML(t *testing.T) {
+	output, err := tomlEncoding{}.Decode(strings.NewReader(`
+# comment
+[[packages]]
+package = 'orders'
+
+[[packages.structs]]
+name = "Order"
+docs = ["first", """
+second"""]
+line = 0x10
+`))
+	require.NoError(t, err)
+	require.Equal(t, "orders", output.Packages[0].Package)
+	require.Equal(t, []string{"first", "second"}, output.Packages[0].Structs[0].Docs)
+	require.Equal(t, 16, output.Packages[0].Structs[0].Line)
+
+	_, err = tomlEncoding{}.Decode(strings.NewReader("a = 1\na = 2\n"))
+	require.Error(t, err)
+	_, err = tomlEncoding{}.Decode(strings.NewReader("[a]\nb = 1\n[a]\nc = 2\n"))
+	require.Error(t, err)
+}
+
+func TestDecodeMsgpackMalformed(t *testing.T) {
+	// Lengths larger than the input must not be allocated up front
+	for _, input := range []string{
+		"\xdd\x7f\xff\xff\xff",
+		"\xdf\x7f\xff\xff\xff",
+		"\xdb\x7f\xff\xff\xff",
+		"\x81\xa1a",
+	} {
+		_, err := msgpackEncoding{}.Decode(strings.NewReader(input))
+		require.Error(t, err)
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestEncodeWriteError(t *testing.T) {
+	output, err := ParseDirectory("./example")
+	require.NoError(t, err)
+	for _, name := range Encodings() {
+		enc, _ := LookupEncoding(name)
+		require.Error(t, enc.Encode(failingWriter{}, output), name)
+	}
+}
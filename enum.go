@@ -0,0 +1,128 @@
+package structparser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+)
+
+// enumBaseTypes are the underlying types a named type may have to be treated as an enum.
+var enumBaseTypes = map[string]constant.Kind{
+	"string":  constant.String,
+	"int":     constant.Int,
+	"int8":    constant.Int,
+	"int16":   constant.Int,
+	"int32":   constant.Int,
+	"int64":   constant.Int,
+	"uint":    constant.Int,
+	"uint8":   constant.Int,
+	"uint16":  constant.Int,
+	"uint32":  constant.Int,
+	"uint64":  constant.Int,
+	"byte":    constant.Int,
+	"rune":    constant.Int,
+	"uintptr": constant.Int,
+}
+
+// extractEnums detects named string/int types that have constants declared with them.
+// Values are listed in declaration order.
+func extractEnums(types []typeDecl, constants []Constant, values map[string]constant.Value) []Enum {
+	enums := make([]Enum, 0)
+	for _, t := range types {
+		if t.Type.Kind != "named" && t.Type.Kind != "alias" {
+			continue
+		}
+		kind, ok := enumBaseTypes[t.Type.Underlying]
+		if !ok {
+			continue
+		}
+
+		enum := Enum{
+			Position: t.Type.Position,
+			Name:     t.Type.Name,
+			Type:     t.Type.Underlying,
+			Values:   make([]EnumValue, 0),
+			Docs:     t.Docs,
+		}
+		for _, c := range constants {
+			if c.Type != t.Type.Name {
+				continue
+			}
+			v, ok := values[c.Name]
+			if !ok || v.Kind() != kind {
+				continue
+			}
+			enum.Values = append(enum.Values, EnumValue{
+				Position: c.Position,
+				Name:     c.Name,
+				Value:    v.ExactString(),
+				Docs:     c.Docs,
+			})
+		}
+		if len(enum.Values) > 0 {
+			enums = append(enums, enum)
+		}
+	}
+	return enums
+}
+
+// evalConstExpr evaluates a constant expression the way the compiler would for the
+// common enum patterns (literals, iota arithmetic, shifts and conversions).
+// It returns an unknown value for anything it cannot evaluate.
+func evalConstExpr(expr ast.Expr, iota int, env map[string]constant.Value) constant.Value {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return constant.MakeFromLiteral(e.Value, e.Kind, 0)
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			return constant.MakeInt64(int64(iota))
+		case "true":
+			return constant.MakeBool(true)
+		case "false":
+			return constant.MakeBool(false)
+		}
+		if v, ok := env[e.Name]; ok {
+			return v
+		}
+	case *ast.ParenExpr:
+		return evalConstExpr(e.X, iota, env)
+	case *ast.UnaryExpr:
+		x := evalConstExpr(e.X, iota, env)
+		if x.Kind() == constant.Unknown {
+			return x
+		}
+		return constant.UnaryOp(e.Op, x, 0)
+	case *ast.BinaryExpr:
+		x := evalConstExpr(e.X, iota, env)
+		y := evalConstExpr(e.Y, iota, env)
+		if x.Kind() == constant.Unknown || y.Kind() == constant.Unknown {
+			return constant.MakeUnknown()
+		}
+		switch e.Op {
+		case token.SHL, token.SHR:
+			s, ok := constant.Uint64Val(y)
+			if !ok {
+				return constant.MakeUnknown()
+			}
+			return constant.Shift(x, e.Op, uint(s))
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			return constant.MakeBool(constant.Compare(x, e.Op, y))
+		case token.QUO, token.REM:
+			if y.Kind() != constant.Int && y.Kind() != constant.Float || constant.Sign(y) == 0 {
+				return constant.MakeUnknown()
+			}
+			if e.Op == token.QUO && x.Kind() == constant.Int && y.Kind() == constant.Int {
+				// Integer division truncates
+				return constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			}
+		}
+		return constant.BinaryOp(x, e.Op, y)
+	case *ast.CallExpr:
+		// Conversions such as Color("red") or string(x)
+		if len(e.Args) == 1 {
+			return evalConstExpr(e.Args[0], iota, env)
+		}
+	}
+	return constant.MakeUnknown()
+}
@@ -0,0 +1,167 @@
+package structparser
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ParseFS parses the Go package in dir of fsys, which can be an os.DirFS, an embed.FS, a
+// zip.Reader or an archive loaded with TarFS or GitFS. Import paths are derived from the
+// nearest go.mod inside fsys.
+func ParseFS(fsys fs.FS, dir string) (*Output, error) {
+	return ParseOptions{}.ParseFS(fsys, dir)
+}
+
+// ParseSources parses in-memory files keyed by file name, for instance editor buffers or
+// test fixtures. Files are grouped into packages by their package clause and selected with
+// the same rules as ParseDirectory; positions use the given file names.
+func ParseSources(sources map[string]string) (*Output, error) {
+	return ParseOptions{}.ParseSources(sources)
+}
+
+type sourceFile struct {
+	name    string
+	content []byte
+}
+
+// sortedSources converts in-memory sources to files ordered by name.
+func sortedSources(sources map[string]string) []sourceFile {
+	names := make([]string, 0, len(sources))
+	for name := range sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make([]sourceFile, len(names))
+	for i, name := range names {
+		files[i] = sourceFile{name: name, content: []byte(sources[name])}
+	}
+	return files
+}
+
+// readFSFiles reads the .go files of dir accepted by filter. File names are joined to
+// root when it is set, so positions point at real paths.
+func readFSFiles(fsys fs.FS, dir string, filter func(fs.FileInfo) bool, root string) ([]sourceFile, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []sourceFile{}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+			continue
+		}
+		if filter != nil {
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+			if !filter(info) {
+				continue
+			}
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		name := path.Join(dir, entry.Name())
+		if root != "" {
+			name = filepath.Join(root, filepath.FromSlash(name))
+		}
+		files = append(files, sourceFile{name: name, content: content})
+	}
+	return files, nil
+}
+
+// parseSourceFiles parses the files selected by rules and groups them by package clause,
+// like parser.ParseDir. Entities record the build constraint of their file. Files cache
+// has declarations for are not parsed again. fset may be shared between goroutines; ctx
+// is checked between files.
+func parseSourceFiles(ctx context.Context, fset *token.FileSet, files []sourceFile, rules fileRules, cache *Cache) (*Output, error) {
+	packages := map[string][]fileDecls{}
+	constraints := map[string]string{}
+	for _, f := range files {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ok, err := rules.match(f.name, f.content)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		if c := fileConstraint(f.name, f.content); c != "" {
+			constraints[f.name] = c
+		}
+		decls, err := extractSource(fset, f, cache)
+		if err != nil {
+			return nil, err
+		}
+		packages[decls.Package] = append(packages[decls.Package], decls)
+	}
+	output := linkPackages(packages)
+	catalogTests(output)
+	if len(constraints) > 0 {
+		forEachPosition(output, func(p *Position, constraint *string) {
+			*constraint = constraints[p.File]
+		})
+	}
+	return output, nil
+}
+
+// extractSource returns the declarations of f, from cache when it is set and has them.
+func extractSource(fset *token.FileSet, f sourceFile, cache *Cache) (fileDecls, error) {
+	var key string
+	if cache != nil {
+		key = cache.key(f)
+		if decls, ok := cache.get(key); ok {
+			return decls, nil
+		}
+	}
+	file, err := parser.ParseFile(fset, f.name, f.content, parser.ParseComments|parser.AllErrors|parser.DeclarationErrors)
+	if err != nil {
+		return fileDecls{}, err
+	}
+	decls, err := extractFile(fset, file)
+	if err != nil {
+		return fileDecls{}, err
+	}
+	if cache != nil {
+		// The cache only saves time, failing to store an entry is not an error
+		_ = cache.put(key, decls)
+	}
+	return decls, nil
+}
+
+// importPathForFS derives the import path of dir from the nearest go.mod above it in fsys.
+func importPathForFS(fsys fs.FS, dir string) string {
+	dir = path.Clean(dir)
+	for current := dir; ; current = path.Dir(current) {
+		content, err := fs.ReadFile(fsys, path.Join(current, "go.mod"))
+		if err == nil {
+			module := modulePath(content)
+			if module == "" {
+				return ""
+			}
+			if current == dir {
+				return module
+			}
+			rel := dir
+			if current != "." {
+				rel = strings.TrimPrefix(dir, current+"/")
+			}
+			return module + "/" + rel
+		}
+		if current == "." {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package structparser
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"embed"
+	"os"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/require"
+)
+
+//go:embed example
+var exampleFS embed.FS
+
+func TestParseFS(t *testing.T) {
+	fromDisk, err := ParseDirectory("./example")
+	require.NoError(t, err)
+
+	t.Run("DirFS", func(t *testing.T) {
+		output, err := ParseFS(os.DirFS("."), "example")
+		require.NoError(t, err)
+		require.Equal(t, fromDisk, output)
+	})
+
+	t.Run("EmbedFS", func(t *testing.T) {
+		output, err := ParseFS(exampleFS, "example")
+		require.NoError(t, err)
+		// There is no go.mod inside the embedded tree
+		require.Equal(t, "", output.Packages[0].Path)
+		output.Packages[0].Path = fromDisk.Packages[0].Path
+		require.Equal(t, fromDisk, output)
+	})
+
+	t.Run("MapFS", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"go.mod":            {Data: []byte("module example.com/shop\n")},
+			"orders/order.go":   {Data: []byte("package orders\n\ntype Order struct{ ID int }\n")},
+			"orders/status.go":  {Data: []byte("package orders\n\ntype Status struct{ Code string }\n")},
+			"orders/README.md":  {Data: []byte("not go")},
+			"orders/sub/sub.go": {Data: []byte("package sub\n")},
+		}
+		output, err := ParseFS(fsys, "orders")
+		require.NoError(t, err)
+		require.Len(t, output.Packages, 1)
+		require.Equal(t, "example.com/shop/orders", output.Packages[0].Path)
+		require.Len(t, output.Packages[0].Structs, 2)
+	})
+}
+
+func TestTarFS(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	files := map[string]string{
+		"repo/go.mod":           "module example.com/repo\n",
+		"repo/models/user.go":   "package models\n\n// User is a user\ntype User struct{ Name string }\n",
+		"repo/models/user.json": "{}",
+	}
+	for _, name := range []string{"repo/go.mod", "repo/models/user.go", "repo/models/user.json"} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(files[name])), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(files[name]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gz.Close())
+
+	fsys, err := TarFS(&buf)
+	require.NoError(t, err)
+	require.NoError(t, fstest.TestFS(fsys, "repo/go.mod", "repo/models/user.go", "repo/models/user.json"))
+
+	output, err := ParseFS(fsys, "repo/models")
+	require.NoError(t, err)
+	require.Equal(t, "example.com/repo/models", output.Packages[0].Path)
+	require.Equal(t, "User", output.Packages[0].Structs[0].Name)
+	require.Equal(t, []string{"User is a user"}, output.Packages[0].Structs[0].Docs)
+}
+
+func TestParseSources(t *testing.T) {
+	sources := map[string]string{
+		"shop/order.go":     "package shop\n\n// Order is an order\ntype Order struct {\n\tID int\n}\n",
+		"shop/cart.go":      "package shop\n\nimport \"time\"\n\ntype Cart struct{ Updated time.Time }\n",
+		"shop/shop_test.go": "package shop_test\n\nfunc helper() {}\n",
+		"shop/never.go":     "//go:build windows && linux\n\npackage shop\n\ntype Never struct{}\n",
+		"shop/ignored.go":   "//go:build ignore\n\npackage main\n",
+		"shop/notes.txt":    "not go",
+	}
+
+	// Test files are excluded by default, build constraints are only evaluated on request
+	output, err := ParseSources(sources)
+	require.NoError(t, err)
+	require.Len(t, output.Packages, 2)
+	require.Len(t, output.Packages[1].Structs, 3)
+
+	output, err = ParseOptions{BuildConstraints: true, GOOS: "linux"}.ParseSources(sources)
+	require.NoError(t, err)
+	require.Len(t, output.Packages, 1)
+
+	shop := output.Packages[0]
+	require.Equal(t, "shop", shop.Package)
+	require.Equal(t, []string{"time"}, shop.Imports)
+	require.Len(t, shop.Structs, 2)
+	names := map[string]Struct{}
+	for _, s := range shop.Structs {
+		names[s.Name] = s
+	}
+	require.Equal(t, Position{File: "shop/order.go", Line: 4}, names["Order"].Position)
+	require.Equal(t, Position{File: "shop/order.go", Line: 5}, names["Order"].Fields[0].Position)
+	require.Equal(t, Position{File: "shop/cart.go", Line: 5}, names["Cart"].Position)
+
+	_, err = ParseSources(map[string]string{"broken.go": "package"})
+	require.Error(t, err)
+}
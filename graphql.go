@@ -0,0 +1,353 @@
+package structparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+)
+
+// GraphQLOptions configures GenerateGraphQL.
+type GraphQLOptions struct {
+	// InputSuffix marks structs whose name ends with it as input types. Defaults to "Input".
+	InputSuffix string
+}
+
+// GenerateGraphQL produces a GraphQL schema with a type (or input) per exported struct and
+// an enum per detected enum.
+//
+// A struct becomes an input when its name ends with InputSuffix. A blank field tagged
+// `graphql:"input"` or `graphql:"type"` selects the kind explicitly, and `graphql:"-"`
+// skips the struct. Field names come from the `graphql` tag, then the `json` tag;
+// `graphql:"-"` skips a field. Fields of untagged embedded structs are flattened into the
+// type, as encoding/json does. Pointer fields are nullable, all others are non-null.
+// Integers that may not fit GraphQL's 32-bit Int use the Int64 scalar. Input fields
+// referring to a struct emitted as a type use an input with the same fields, named with
+// InputSuffix.
+func GenerateGraphQL(out *Output, opts GraphQLOptions) ([]byte, error) {
+	if opts.InputSuffix == "" {
+		opts.InputSuffix = "Input"
+	}
+
+	g := &graphQLGenerator{
+		inputSuffix: opts.InputSuffix,
+		kinds:       map[string]string{},
+		structs:     map[string]Struct{},
+		scalars:     map[string]bool{},
+		derived:     map[string]bool{},
+	}
+	for _, pkg := range out.Packages {
+		for _, s := range pkg.Structs {
+			g.structs[s.Name] = s
+		}
+	}
+	// Select what is emitted first, so that fields only refer to types of the schema
+	for _, pkg := range out.Packages {
+		for _, e := range pkg.Enums {
+			if ast.IsExported(e.Name) {
+				g.kinds[e.Name] = "enum"
+			}
+		}
+		for _, s := range pkg.Structs {
+			if kind, ok := graphQLKind(s, opts.InputSuffix); ok && len(g.fields(s, map[string]bool{})) > 0 {
+				g.kinds[s.Name] = kind
+			}
+		}
+	}
+
+	var body bytes.Buffer
+	for _, pkg := range out.Packages {
+		for _, e := range pkg.Enums {
+			if !ast.IsExported(e.Name) {
+				continue
+			}
+			writeGraphQLDescription(&body, "", e.Docs)
+			fmt.Fprintf(&body, "enum %s {\n", e.Name)
+			for _, v := range e.Values {
+				writeGraphQLDescription(&body, "  ", v.Docs)
+				fmt.Fprintf(&body, "  %s\n", graphQLEnumValue(e.Name, v.Name))
+			}
+			body.WriteString("}\n\n")
+		}
+
+		for _, s := range pkg.Structs {
+			if kind, ok := graphQLKind(s, opts.InputSuffix); ok && g.kinds[s.Name] == kind {
+				g.writeObject(&body, kind, s.Name, s)
+			}
+		}
+	}
+	// Inputs standing for types are written once every reference to them is known
+	for len(g.pending) > 0 {
+		s := g.structs[g.pending[0]]
+		g.pending = g.pending[1:]
+		g.writeObject(&body, "input", s.Name+opts.InputSuffix, s)
+	}
+
+	var buf bytes.Buffer
+	scalars := make([]string, 0, len(g.scalars))
+	for k := range g.scalars {
+		scalars = append(scalars, k)
+	}
+	sort.Strings(scalars)
+	for _, k := range scalars {
+		fmt.Fprintf(&buf, "scalar %s\n", k)
+	}
+	if len(scalars) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.Write(bytes.TrimRight(body.Bytes(), "\n"))
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+// graphQLKind returns whether s is emitted as a "type" or an "input", and false when it
+// is not emitted.
+func graphQLKind(s Struct, inputSuffix string) (string, bool) {
+	if !ast.IsExported(s.Name) {
+		return "", false
+	}
+	kind := "type"
+	if strings.HasSuffix(s.Name, inputSuffix) {
+		kind = "input"
+	}
+	for _, f := range s.Fields {
+		if f.Name != "_" {
+			continue
+		}
+		switch tag, _, _ := lookupTag(f.Tag, "graphql"); tag {
+		case "-":
+			return "", false
+		case "input", "type":
+			kind = tag
+		}
+	}
+	return kind, true
+}
+
+type graphQLGenerator struct {
+	inputSuffix string
+	kinds       map[string]string // Kind of the types emitted: "type", "input" or "enum"
+	structs     map[string]Struct // Structs by name, for flattening embedded ones
+	scalars     map[string]bool   // Custom scalars referenced by the schema
+	derived     map[string]bool   // Types used by inputs, which get an input of their own
+	pending     []string          // Derived inputs not written yet
+}
+
+// graphQLField is a field of a struct with its GraphQL name.
+type graphQLField struct {
+	name  string
+	field Field
+}
+
+// writeObject writes the type or input name with the fields of s.
+func (g *graphQLGenerator) writeObject(buf *bytes.Buffer, kind, name string, s Struct) {
+	writeGraphQLDescription(buf, "", s.Docs)
+	fmt.Fprintf(buf, "%s %s {\n", kind, name)
+	for _, f := range g.fields(s, map[string]bool{}) {
+		typ := g.typeOf(parseTypeString(f.field.Type), true, kind == "input")
+		if f.field.Name == "ID" && (typ == "String!" || typ == "Int!" || typ == "Int64!") {
+			typ = "ID!"
+		}
+		docs := append([]string{}, f.field.Docs...)
+		if f.field.Comment != "" {
+			docs = append(docs, f.field.Comment)
+		}
+		writeGraphQLDescription(buf, "  ", docs)
+		fmt.Fprintf(buf, "  %s: %s\n", f.name, typ)
+	}
+	buf.WriteString("}\n\n")
+}
+
+// fields returns the fields of s in order, flattening untagged embedded structs. A field
+// declared by s hides fields of the same name from embedded structs.
+func (g *graphQLGenerator) fields(s Struct, seen map[string]bool) []graphQLField {
+	seen[s.Name] = true
+	defer delete(seen, s.Name)
+
+	fields := []graphQLField{}
+	embedded := [][]graphQLField{}
+	declared := map[string]bool{}
+	for _, f := range s.Fields {
+		if f.Name == "" {
+			jsonTag, _, _ := lookupTag(f.Tag, "json")
+			graphQLTag, _, _ := lookupTag(f.Tag, "graphql")
+			inner, ok := g.structs[embeddedTypeName(f)]
+			if ok && jsonTag == "" && graphQLTag == "" && !seen[inner.Name] {
+				fields = append(fields, graphQLField{})
+				embedded = append(embedded, g.fields(inner, seen))
+				continue
+			}
+			f.Name = embeddedTypeName(f)
+		}
+		name, ok := graphQLFieldName(f)
+		if !ok {
+			continue
+		}
+		fields = append(fields, graphQLField{name: name, field: f})
+		declared[name] = true
+	}
+
+	// Empty entries mark where embedded fields go
+	flattened := []graphQLField{}
+	for _, f := range fields {
+		if f.name != "" {
+			flattened = append(flattened, f)
+			continue
+		}
+		for _, inner := range embedded[0] {
+			if !declared[inner.name] {
+				declared[inner.name] = true
+				flattened = append(flattened, inner)
+			}
+		}
+		embedded = embedded[1:]
+	}
+	return flattened
+}
+
+// ref returns the name a field refers to the type name by, or "" when the schema does not
+// have it. Input fields cannot refer to types, so they use the input with the fields of
+// the type instead: a struct of that name when there is one, or else a derived input.
+func (g *graphQLGenerator) ref(name string, input bool) string {
+	kind, ok := g.kinds[name]
+	switch {
+	case !ok:
+		return ""
+	case !input || kind != "type":
+		return name
+	}
+	inputName := name + g.inputSuffix
+	if g.kinds[inputName] == "input" {
+		return inputName
+	}
+	if !g.derived[name] {
+		g.derived[name] = true
+		g.pending = append(g.pending, name)
+	}
+	return inputName
+}
+
+// typeOf maps a Go type expression to a GraphQL type reference, for a field of an input
+// when input is set.
+func (g *graphQLGenerator) typeOf(expr ast.Expr, nonNull, input bool) string {
+	typ := "JSON"
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return g.typeOf(e.X, false, input)
+	case *ast.Ident:
+		switch e.Name {
+		case "string":
+			typ = "String"
+		case "bool":
+			typ = "Boolean"
+		case "int8", "int16", "int32", "uint8", "uint16", "byte", "rune":
+			typ = "Int"
+		case "int", "int64", "uint", "uint32", "uint64", "uintptr":
+			typ = "Int64"
+		case "float32", "float64":
+			typ = "Float"
+		default:
+			if ref := g.ref(e.Name, input); ref != "" {
+				typ = ref
+			}
+		}
+	case *ast.SelectorExpr:
+		switch justTypeString(getType(e)) {
+		case "time.Time":
+			typ = "Time"
+		case "time.Duration":
+			typ = "Int64"
+		default:
+			if ref := g.ref(e.Sel.Name, input); ref != "" {
+				typ = ref
+			}
+		}
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") && e.Len == nil {
+			typ = "String"
+			break
+		}
+		typ = "[" + g.typeOf(e.Elt, true, input) + "]"
+	}
+	if typ == "JSON" || typ == "Time" || typ == "Int64" {
+		g.scalars[typ] = true
+	}
+	if nonNull {
+		return typ + "!"
+	}
+	return typ
+}
+
+func graphQLFieldName(f Field) (string, bool) {
+	if f.Private {
+		return "", false
+	}
+	if name, _, ok := lookupTag(f.Tag, "graphql"); ok {
+		if name == "-" {
+			return "", false
+		}
+		if name != "" {
+			return name, true
+		}
+	}
+	name, _, ok := jsonName(f)
+	return name, ok
+}
+
+// graphQLEnumValue converts a constant name to an enum value, dropping the enum name
+// prefix (e.g., ColorDarkRed of Color becomes DARK_RED).
+func graphQLEnumValue(enum, name string) string {
+	if trimmed := strings.TrimPrefix(name, enum); trimmed != "" && trimmed != name {
+		name = trimmed
+	}
+	return toScreamingSnake(name)
+}
+
+func writeGraphQLDescription(buf *bytes.Buffer, indent string, docs []string) {
+	switch len(docs) {
+	case 0:
+		return
+	case 1:
+		fmt.Fprintf(buf, "%s%s\n", indent, graphQLString(docs[0]))
+		return
+	}
+	fmt.Fprintf(buf, "%s\"\"\"\n", indent)
+	for _, d := range docs {
+		fmt.Fprintf(buf, "%s%s\n", indent, strings.ReplaceAll(d, `"""`, `\"""`))
+	}
+	fmt.Fprintf(buf, "%s\"\"\"\n", indent)
+}
+
+// graphQLString quotes s as a GraphQL string value. Unlike Go quoting it only uses the
+// escapes GraphQL has: \", \\, \n and friends, and \uXXXX for other control characters.
+func graphQLString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
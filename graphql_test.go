@@ -0,0 +1,142 @@
+package structparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateGraphQL(t *testing.T) {
+	code := `
+	package gql
+
+	// Color of a product
+	type Color int
+
+	const (
+		ColorRed Color = iota
+		ColorDarkBlue
+	)
+
+	type Audit struct {
+		CreatedAt time.Time ` + "`json:\"createdAt\"`" + `
+		Version   int64     ` + "`json:\"version\"`" + `
+	}
+
+	// Product is sold in the shop
+	// It has many colors
+	type Product struct {
+		Audit
+		ID        string            ` + "`json:\"id\"`" + `
+		Name      string            ` + "`json:\"name\"`" + ` // display "name" in café` + "\x01" + `
+		Stock     int32             ` + "`json:\"stock\"`" + `
+		Price     *float64          ` + "`json:\"price\"`" + `
+		Colors    []Color           ` + "`json:\"colors\"`" + `
+		Related   []*Product        ` + "`graphql:\"related\"`" + `
+		Attrs     map[string]string ` + "`json:\"attrs\"`" + `
+		CreatedAt time.Time         ` + "`json:\"createdAt\"`" + `
+		Internal  string            ` + "`graphql:\"-\"`" + `
+	}
+
+	type ProductInput struct {
+		Name string ` + "`json:\"name\"`" + `
+	}
+
+	type Filter struct {
+		_       struct{} ` + "`graphql:\"input\"`" + `
+		Query   *string  ` + "`json:\"query\"`" + `
+		Product Product  ` + "`json:\"product\"`" + `
+	}
+
+	type Hidden struct {
+		_    struct{} ` + "`graphql:\"-\"`" + `
+		Name string
+	}
+
+	type level int
+
+	const (
+		low level = iota
+		high
+	)
+
+	type meta struct {
+		Key string
+	}
+
+	type Empty struct{}
+
+	type Order struct {
+		Level level ` + "`json:\"level\"`" + `
+		Meta  meta  ` + "`json:\"meta\"`" + `
+		Empty Empty ` + "`json:\"empty\"`" + `
+		Total int32 ` + "`json:\"total\"`" + `
+	}
+	`
+	output, err := ParseString(code)
+	require.NoError(t, err)
+
+	schema, err := GenerateGraphQL(output, GraphQLOptions{})
+	require.NoError(t, err)
+	got := string(schema)
+
+	require.Contains(t, got, "scalar Int64\nscalar JSON\nscalar Time\n")
+	require.Contains(t, got, "\"Color of a product\"\nenum Color {\n  RED\n  DARK_BLUE\n}")
+	require.Contains(t, got, `"""
+Product is sold in the shop
+It has many colors
+"""
+type Product {
+  version: Int64!
+  id: ID!
+  "display \"name\" in café\u0001"
+  name: String!
+  stock: Int!
+  price: Float
+  colors: [Color!]!
+  related: [Product]!
+  attrs: JSON!
+  createdAt: Time!
+}`)
+	require.Contains(t, got, "input ProductInput {\n  name: String!\n}")
+	require.NotContains(t, got, "Hidden")
+
+	// Inputs refer to an input with the fields of a type, not to the type
+	require.Contains(t, got, "input Filter {\n  query: String\n  product: ProductInput!\n}")
+	require.Contains(t, got, "input ProductInput {\n  name: String!\n}")
+
+	// Types that are not emitted are not referred to
+	require.Contains(t, got, "type Order {\n  level: JSON!\n  meta: JSON!\n  empty: JSON!\n  total: Int!\n}")
+	require.NotContains(t, got, "Empty {")
+	require.NotContains(t, got, "Internal")
+}
+
+func TestGenerateGraphQLDerivedInput(t *testing.T) {
+	output, err := ParseString(`package gql
+
+type Address struct {
+	City string ` + "`json:\"city\"`" + `
+}
+
+type Customer struct {
+	Name    string   ` + "`json:\"name\"`" + `
+	Address *Address ` + "`json:\"address\"`" + `
+}
+
+type SignupInput struct {
+	Customer Customer ` + "`json:\"customer\"`" + `
+}
+`)
+	require.NoError(t, err)
+	schema, err := GenerateGraphQL(output, GraphQLOptions{})
+	require.NoError(t, err)
+	got := string(schema)
+
+	// Types used by inputs get an input of their own, down to nested ones
+	require.Contains(t, got, "type Customer {\n  name: String!\n  address: Address\n}")
+	require.Contains(t, got, "input SignupInput {\n  customer: CustomerInput!\n}")
+	require.Contains(t, got, "input CustomerInput {\n  name: String!\n  address: AddressInput\n}")
+	require.Contains(t, got, "input AddressInput {\n  city: String!\n}")
+	require.Equal(t, 1, strings.Count(got, "input CustomerInput"))
+}
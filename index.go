@@ -0,0 +1,214 @@
+package structparser
+
+import "strings"
+
+// Index answers name lookups over the packages of an Output.
+//
+// Names may be qualified by package name or import path ("models.User",
+// "example.com/app/models.User"). A name, qualified or not, only resolves when it is
+// unique: "User" declared in two packages, or "models.User" in two packages named models,
+// is reported as absent, and the qualified form must be used.
+type Index struct {
+	structs    lookup[Struct]
+	interfaces lookup[Interface]
+	functions  lookup[Function]
+	variables  lookup[Variable]
+	constants  lookup[Constant]
+	enums      lookup[Enum]
+	types      lookup[Type]
+
+	receivers lookup[Method]
+	tags      map[string][]Entity
+	returns   map[string][]Entity
+	files     map[string][]Entity
+}
+
+// lookup maps every name an entity can be referred to by to the entities with that name.
+type lookup[T any] map[string][]T
+
+func (l lookup[T]) add(pkg Package, name string, v T) {
+	keys := []string{name, pkg.Package + "." + name}
+	if pkg.Path != "" && pkg.Path != pkg.Package {
+		keys = append(keys, pkg.Path+"."+name)
+	}
+	for _, key := range keys {
+		l[key] = append(l[key], v)
+	}
+}
+
+func (l lookup[T]) get(name string) (T, bool) {
+	if found := l[name]; len(found) == 1 {
+		return found[0], true
+	}
+	var zero T
+	return zero, false
+}
+
+// NewIndex indexes the packages of out. Later changes to out are not reflected.
+func NewIndex(out *Output) *Index {
+	x := &Index{
+		structs:    lookup[Struct]{},
+		interfaces: lookup[Interface]{},
+		functions:  lookup[Function]{},
+		variables:  lookup[Variable]{},
+		constants:  lookup[Constant]{},
+		enums:      lookup[Enum]{},
+		types:      lookup[Type]{},
+		receivers:  lookup[Method]{},
+		tags:       map[string][]Entity{},
+		returns:    map[string][]Entity{},
+		files:      map[string][]Entity{},
+	}
+	for _, pkg := range out.Packages {
+		pkgName := entityPackage(pkg)
+		entity := func(kind, name string, pos Position) Entity {
+			return Entity{Package: pkgName, Kind: kind, Name: name, Position: pos}
+		}
+		byFile := func(e Entity) {
+			x.files[e.File] = append(x.files[e.File], e)
+		}
+		byReturns := func(e Entity, returns []Param) {
+			for _, r := range returns {
+				x.returns[r.Type] = append(x.returns[r.Type], e)
+			}
+		}
+
+		for _, s := range pkg.Structs {
+			x.structs.add(pkg, s.Name, s)
+			byFile(entity("struct", s.Name, s.Position))
+			for _, f := range s.Fields {
+				name := f.Name
+				if name == "" {
+					name = embeddedTypeName(f)
+				}
+				for _, key := range tagKeys(f.Tag) {
+					x.tags[key] = append(x.tags[key], entity("field", s.Name+"."+name, f.Position))
+				}
+			}
+		}
+		for _, t := range pkg.Types {
+			x.types.add(pkg, t.Name, t)
+			for _, m := range t.Methods {
+				x.receivers.add(pkg, t.Name, m)
+				e := entity("method", t.Name+"."+m.Name, m.Position)
+				byFile(e)
+				byReturns(e, m.Returns)
+			}
+		}
+		for _, i := range pkg.Interfaces {
+			x.interfaces.add(pkg, i.Name, i)
+			byFile(entity("interface", i.Name, i.Position))
+		}
+		for _, f := range pkg.Functions {
+			x.functions.add(pkg, f.Name, f)
+			e := entity("function", f.Name, f.Position)
+			byFile(e)
+			byReturns(e, f.Returns)
+		}
+		for _, v := range pkg.Variables {
+			x.variables.add(pkg, v.Name, v)
+			byFile(entity("variable", v.Name, v.Position))
+		}
+		for _, c := range pkg.Constants {
+			x.constants.add(pkg, c.Name, c)
+			byFile(entity("constant", c.Name, c.Position))
+		}
+		for _, e := range pkg.Enums {
+			x.enums.add(pkg, e.Name, e)
+			byFile(entity("enum", e.Name, e.Position))
+		}
+	}
+	return x
+}
+
+// Struct returns the struct called name.
+func (x *Index) Struct(name string) (Struct, bool) {
+	return x.structs.get(name)
+}
+
+// Interface returns the interface called name.
+func (x *Index) Interface(name string) (Interface, bool) {
+	return x.interfaces.get(name)
+}
+
+// Function returns the package level function called name.
+func (x *Index) Function(name string) (Function, bool) {
+	return x.functions.get(name)
+}
+
+// Variable returns the package level variable called name.
+func (x *Index) Variable(name string) (Variable, bool) {
+	return x.variables.get(name)
+}
+
+// Constant returns the package level constant called name.
+func (x *Index) Constant(name string) (Constant, bool) {
+	return x.constants.get(name)
+}
+
+// Enum returns the enum called name.
+func (x *Index) Enum(name string) (Enum, bool) {
+	return x.enums.get(name)
+}
+
+// Type returns the named type called name, with its method sets.
+func (x *Index) Type(name string) (Type, bool) {
+	return x.types.get(name)
+}
+
+// Field returns a field of the struct called structName. Embedded fields are found by
+// type name (e.g., "Base" for *models.Base).
+func (x *Index) Field(structName, name string) (Field, bool) {
+	s, ok := x.Struct(structName)
+	if !ok {
+		return Field{}, false
+	}
+	for _, f := range s.Fields {
+		if f.Name == name || f.Name == "" && embeddedTypeName(f) == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// Method returns a method of the struct or interface called typeName.
+func (x *Index) Method(typeName, name string) (Method, bool) {
+	var methods []Method
+	if s, ok := x.Struct(typeName); ok {
+		methods = s.Methods
+	} else if i, ok := x.Interface(typeName); ok {
+		methods = i.Methods
+	}
+	for _, m := range methods {
+		if m.Name == name {
+			return m, true
+		}
+	}
+	return Method{}, false
+}
+
+// ByReceiver returns the methods declared with typeName as receiver, whether a value or a
+// pointer, including types that are not structs. Promoted methods are not included; they
+// are in the method sets of Type. An ambiguous unqualified name returns the methods of
+// every type with that name.
+func (x *Index) ByReceiver(typeName string) []Method {
+	return x.receivers[strings.TrimPrefix(typeName, "*")]
+}
+
+// ByTag returns the struct fields whose tag has key (e.g., "json", "db"), named
+// "Struct.Field".
+func (x *Index) ByTag(key string) []Entity {
+	return x.tags[key]
+}
+
+// ByReturnType returns the functions and methods with a result of type typ, spelled as
+// in the source (e.g., "*User", "[]models.User", "error").
+func (x *Index) ByReturnType(typ string) []Entity {
+	return x.returns[typ]
+}
+
+// ByFile returns the structs, interfaces, methods, functions, variables, constants and
+// enums declared in file, using the file names of the Output.
+func (x *Index) ByFile(file string) []Entity {
+	return x.files[file]
+}
@@ -0,0 +1,130 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIndex(t *testing.T) {
+	out := &Output{Packages: []Package{
+		{
+			Package: "models",
+			Path:    "example.com/app/models",
+			Structs: []Struct{{
+				Name: "User",
+				Fields: []Field{
+					{Name: "ID", Type: "int", Tag: `json:"id" db:"id"`},
+					{Type: "*Base"},
+				},
+				Methods:  []Method{{Name: "Save", Receiver: "*User", Returns: []Param{{Type: "error"}}, Position: Position{File: "models/user.go", Line: 9}}},
+				Position: Position{File: "models/user.go", Line: 3},
+			}},
+			Types: []Type{{
+				Name:     "User",
+				Kind:     "struct",
+				Methods:  []Method{{Name: "Save", Receiver: "*User", Returns: []Param{{Type: "error"}}, Position: Position{File: "models/user.go", Line: 9}}},
+				Position: Position{File: "models/user.go", Line: 3},
+			}},
+			Functions: []Function{{Name: "NewUser", Returns: []Param{{Type: "*User"}, {Type: "error"}}, Position: Position{File: "models/user.go", Line: 12}}},
+			Constants: []Constant{{Name: "Version", Value: "1"}},
+		},
+		{
+			Package:    "api",
+			Path:       "example.com/app/api",
+			Structs:    []Struct{{Name: "User", Fields: []Field{{Name: "Login", Tag: `json:"login"`}}}},
+			Interfaces: []Interface{{Name: "Store", Methods: []Method{{Name: "Get"}}}},
+			Variables:  []Variable{{Name: "Default"}},
+			Enums:      []Enum{{Name: "Role"}},
+			Types:      []Type{{Name: "Store", Kind: "interface", MethodSet: []MethodSetEntry{{Name: "Get", Signature: "func()", Receiver: "Store"}}}},
+		},
+	}}
+	x := NewIndex(out)
+
+	// User is declared twice, so it needs a qualifier
+	_, ok := x.Struct("User")
+	require.False(t, ok)
+	user, ok := x.Struct("models.User")
+	require.True(t, ok)
+	require.Equal(t, "ID", user.Fields[0].Name)
+	user, ok = x.Struct("example.com/app/api.User")
+	require.True(t, ok)
+	require.Equal(t, "Login", user.Fields[0].Name)
+	_, ok = x.Struct("models.Missing")
+	require.False(t, ok)
+
+	f, ok := x.Field("models.User", "Base")
+	require.True(t, ok)
+	require.Equal(t, "*Base", f.Type)
+	_, ok = x.Field("models.User", "Missing")
+	require.False(t, ok)
+	m, ok := x.Method("models.User", "Save")
+	require.True(t, ok)
+	require.Equal(t, "*User", m.Receiver)
+	_, ok = x.Method("Store", "Get")
+	require.True(t, ok)
+
+	_, ok = x.Function("NewUser")
+	require.True(t, ok)
+	_, ok = x.Constant("models.Version")
+	require.True(t, ok)
+	_, ok = x.Variable("api.Default")
+	require.True(t, ok)
+	_, ok = x.Enum("Role")
+	require.True(t, ok)
+	_, ok = x.Interface("models.Store")
+	require.False(t, ok)
+	typ, ok := x.Type("api.Store")
+	require.True(t, ok)
+	require.Len(t, typ.MethodSet, 1)
+
+	require.Len(t, x.ByReceiver("*models.User"), 1)
+	require.Empty(t, x.ByReceiver("api.User"))
+
+	var names []string
+	for _, e := range x.ByTag("json") {
+		names = append(names, e.Package+" "+e.Name)
+	}
+	require.Equal(t, []string{"example.com/app/models User.ID", "example.com/app/api User.Login"}, names)
+	require.Len(t, x.ByTag("db"), 1)
+
+	require.Equal(t, []Entity{
+		{Package: "example.com/app/models", Kind: "method", Name: "User.Save", Position: Position{File: "models/user.go", Line: 9}},
+		{Package: "example.com/app/models", Kind: "function", Name: "NewUser", Position: Position{File: "models/user.go", Line: 12}},
+	}, x.ByReturnType("error"))
+	require.Len(t, x.ByReturnType("*User"), 1)
+
+	names = nil
+	for _, e := range x.ByFile("models/user.go") {
+		names = append(names, e.Kind+" "+e.Name)
+	}
+	require.Equal(t, []string{"struct User", "method User.Save", "function NewUser"}, names)
+}
+
+func TestIndexDeclaredMethods(t *testing.T) {
+	out, err := ParseSources(map[string]string{
+		"base.go": "package models\n\ntype Base struct{}\n\nfunc (b *Base) Touch() {}\n",
+		"user.go": "package models\n\ntype Color int\n\nfunc (c Color) String() string { return \"\" }\n\ntype User struct {\n\tBase\n}\n\nfunc (u User) Name() string { return \"\" }\n",
+	})
+	require.NoError(t, err)
+	x := NewIndex(out)
+
+	names := func(methods []Method) []string {
+		var names []string
+		for _, m := range methods {
+			names = append(names, m.Receiver+"."+m.Name)
+		}
+		return names
+	}
+	require.Equal(t, []string{"Color.String"}, names(x.ByReceiver("Color")))
+	// Touch is promoted from Base, so it is not declared on User
+	require.Equal(t, []string{"User.Name"}, names(x.ByReceiver("User")))
+	require.Equal(t, []string{"*Base.Touch"}, names(x.ByReceiver("*Base")))
+
+	var entities []string
+	for _, e := range x.ByFile("user.go") {
+		entities = append(entities, e.Kind+" "+e.Name)
+	}
+	require.Equal(t, []string{"struct User", "method Color.String", "method User.Name"}, entities)
+	require.Len(t, x.ByReturnType("string"), 2)
+}
@@ -0,0 +1,386 @@
+package structparser
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+)
+
+// StructLayout is the memory layout of a struct and the field order minimizing its size.
+type StructLayout struct {
+	Package      string   `json:"package"`
+	Name         string   `json:"name"`
+	Size         int64    `json:"size,omitempty"`
+	Align        int64    `json:"align,omitempty"`
+	Padding      int64    `json:"padding,omitempty"`      // Bytes between and after the fields
+	OptimalSize  int64    `json:"optimalSize,omitempty"`  // Size with the fields in OptimalOrder
+	OptimalOrder []string `json:"optimalOrder,omitempty"` // Field names, only when reordering saves space
+	Unresolved   string   `json:"unresolved,omitempty"`   // Why the layout is unknown, in which case it is empty
+	Position
+}
+
+// knownLayouts gives the layout of common standard library and third-party types, keyed
+// by import path and name, for packages outside the Output. Only the sizes and alignments
+// of the fields matter.
+var knownLayouts = map[string]string{
+	"time.Time":          "struct{ wall uint64; ext int64; loc *int }",
+	"time.Duration":      "int64",
+	"time.Month":         "int",
+	"time.Weekday":       "int",
+	"time.Location":      "struct{ name string; zone []int; tx []int; extend string; cacheStart, cacheEnd int64; cacheZone *int }",
+	"sync.Mutex":         "struct{ state int32; sema uint32 }",
+	"sync.RWMutex":       "struct{ w sync.Mutex; writerSem, readerSem uint32; readerCount, readerWait int32 }",
+	"sync.Once":          "struct{ done uint32; m sync.Mutex }",
+	"sync.WaitGroup":     "struct{ noCopy struct{}; state uint64; sema uint32 }",
+	"sync/atomic.Bool":   "struct{ v uint32 }",
+	"sync/atomic.Int32":  "struct{ v int32 }",
+	"sync/atomic.Int64":  "struct{ v int64 }",
+	"sync/atomic.Uint32": "struct{ v uint32 }",
+	"sync/atomic.Uint64": "struct{ v uint64 }",
+	"sync/atomic.Value":  "interface{}",
+	"context.Context":    "interface{}",
+	"fmt.Stringer":       "interface{}",
+	"io.Reader":          "interface{}",
+	"io.Writer":          "interface{}",
+	"io.ReadCloser":      "interface{}",
+	"io.Closer":          "interface{}",
+	"net/http.Handler":   "interface{}",
+
+	"encoding/json.RawMessage":                            "[]byte",
+	"encoding/json.Number":                                "string",
+	"database/sql.NullString":                             "struct{ String string; Valid bool }",
+	"database/sql.NullInt64":                              "struct{ Int64 int64; Valid bool }",
+	"database/sql.NullInt32":                              "struct{ Int32 int32; Valid bool }",
+	"database/sql.NullFloat64":                            "struct{ Float64 float64; Valid bool }",
+	"database/sql.NullBool":                               "struct{ Bool bool; Valid bool }",
+	"database/sql.NullTime":                               "struct{ Time time.Time; Valid bool }",
+	"github.com/google/uuid.UUID":                         "[16]byte",
+	"github.com/shopspring/decimal.Decimal":               "struct{ value *int; exp int32 }",
+	"go.mongodb.org/mongo-driver/bson/primitive.ObjectID": "[12]byte",
+}
+
+// align64Layouts are the types of knownLayouts the compiler aligns to 8 bytes on every
+// architecture. Where uint64 is less aligned, as on 386 and arm, that cannot be expressed
+// with go/types sizes, so their layout is reported as unknown there.
+var align64Layouts = map[string]bool{
+	"sync.WaitGroup":     true,
+	"sync/atomic.Int64":  true,
+	"sync/atomic.Uint64": true,
+}
+
+// ComputeLayout sets the Size and Align of the structs of out, and the Offset and Size of
+// their fields, as laid out by the gc compiler for goarch. Field types are resolved in the
+// packages of out and among common library types; structs with a field of any other type
+// are left unchanged.
+func ComputeLayout(out *Output, goarch string) error {
+	l, err := newLayouter(out, goarch)
+	if err != nil {
+		return err
+	}
+	for p := range out.Packages {
+		for k, s := range out.Packages[p].Structs {
+			st, err := l.structOf(p, s)
+			if err != nil {
+				continue
+			}
+			s.Size, s.Align = l.sizes.Sizeof(st), l.sizes.Alignof(st)
+			offsets := l.sizes.Offsetsof(fieldVars(st))
+			for i := range s.Fields {
+				s.Fields[i].Offset = offsets[i]
+				s.Fields[i].Size = l.sizes.Sizeof(st.Field(i).Type())
+			}
+			out.Packages[p].Structs[k] = s
+		}
+	}
+	return nil
+}
+
+// AnalyzeLayout reports the layout of every struct of out for goarch, resolving field
+// types like ComputeLayout, with the field order that avoids padding: zero-sized fields
+// first, then by decreasing alignment and size.
+func AnalyzeLayout(out *Output, goarch string) ([]StructLayout, error) {
+	l, err := newLayouter(out, goarch)
+	if err != nil {
+		return nil, err
+	}
+	layouts := []StructLayout{}
+	for p, pkg := range out.Packages {
+		for _, s := range pkg.Structs {
+			layout := StructLayout{Package: entityPackage(pkg), Name: s.Name, Position: s.Position}
+			st, err := l.structOf(p, s)
+			if err != nil {
+				layout.Unresolved = err.Error()
+				layouts = append(layouts, layout)
+				continue
+			}
+			layout.Size, layout.Align = l.sizes.Sizeof(st), l.sizes.Alignof(st)
+			layout.Padding = layout.Size
+			for i := 0; i < st.NumFields(); i++ {
+				layout.Padding -= l.sizes.Sizeof(st.Field(i).Type())
+			}
+
+			order := make([]int, st.NumFields())
+			for i := range order {
+				order[i] = i
+			}
+			sort.SliceStable(order, func(i, j int) bool {
+				ti, tj := st.Field(order[i]).Type(), st.Field(order[j]).Type()
+				si, sj := l.sizes.Sizeof(ti), l.sizes.Sizeof(tj)
+				if (si == 0) != (sj == 0) {
+					return si == 0
+				}
+				if ai, aj := l.sizes.Alignof(ti), l.sizes.Alignof(tj); ai != aj {
+					return ai > aj
+				}
+				return si > sj
+			})
+			fields := make([]*types.Var, len(order))
+			for i, k := range order {
+				fields[i] = st.Field(k)
+			}
+			layout.OptimalSize = l.sizes.Sizeof(types.NewStruct(fields, nil))
+			if layout.OptimalSize < layout.Size {
+				for _, k := range order {
+					layout.OptimalOrder = append(layout.OptimalOrder, fieldName(s.Fields[k]))
+				}
+			}
+			layouts = append(layouts, layout)
+		}
+	}
+	return layouts, nil
+}
+
+func fieldVars(st *types.Struct) []*types.Var {
+	fields := make([]*types.Var, st.NumFields())
+	for i := range fields {
+		fields[i] = st.Field(i)
+	}
+	return fields
+}
+
+// layouter builds go/types types from the field types of an Output to size them.
+type layouter struct {
+	out      *Output
+	sizes    types.Sizes
+	packages map[string][]int // Indexes of the packages of out by name
+
+	resolved map[typeKey]types.Type
+	pending  map[typeKey]bool
+}
+
+// typeKey identifies a declared type by package index, -1 for knownLayouts.
+type typeKey struct {
+	pkg  int
+	name string
+}
+
+var emptyInterface = types.NewInterfaceType(nil, nil)
+
+func newLayouter(out *Output, goarch string) (*layouter, error) {
+	sizes := types.SizesFor("gc", goarch)
+	if sizes == nil {
+		return nil, fmt.Errorf("unknown architecture %q", goarch)
+	}
+	l := &layouter{
+		out:      out,
+		sizes:    sizes,
+		packages: map[string][]int{},
+		resolved: map[typeKey]types.Type{},
+		pending:  map[typeKey]bool{},
+	}
+	for p, pkg := range out.Packages {
+		l.packages[pkg.Package] = append(l.packages[pkg.Package], p)
+	}
+	return l, nil
+}
+
+// structOf returns the type of the struct s of package p.
+func (l *layouter) structOf(p int, s Struct) (*types.Struct, error) {
+	typ, err := l.declared(p, s.Name)
+	if err != nil {
+		return nil, err
+	}
+	st, ok := typ.(*types.Struct)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a struct", s.Name)
+	}
+	return st, nil
+}
+
+// declared returns the type declared as name in package p, or in knownLayouts when p is -1.
+// It returns nil without error when there is no such declaration.
+func (l *layouter) declared(p int, name string) (types.Type, error) {
+	key := typeKey{p, name}
+	if typ, ok := l.resolved[key]; ok {
+		return typ, nil
+	}
+	if l.pending[key] {
+		return nil, fmt.Errorf("invalid recursive type %s", name)
+	}
+	l.pending[key] = true
+	defer delete(l.pending, key)
+
+	var (
+		typ types.Type
+		err error
+	)
+	if p < 0 {
+		expr, ok := knownLayouts[name]
+		if !ok {
+			return nil, nil
+		}
+		if align64Layouts[name] && l.sizes.Alignof(types.Typ[types.Uint64]) < 8 {
+			return nil, fmt.Errorf("unknown layout of %s, which is 8-byte aligned on every architecture", name)
+		}
+		typ, err = l.typeString(p, expr)
+	} else {
+		typ, err = l.packageType(p, name)
+	}
+	if err != nil || typ == nil {
+		return nil, err
+	}
+	l.resolved[key] = typ
+	return typ, nil
+}
+
+func (l *layouter) packageType(p int, name string) (types.Type, error) {
+	pkg := l.out.Packages[p]
+	for _, s := range pkg.Structs {
+		if s.Name != name {
+			continue
+		}
+		// Fields are blank so that invalid declarations with duplicate names are sized too
+		fields := make([]*types.Var, len(s.Fields))
+		for i, f := range s.Fields {
+			typ, err := l.typeString(p, f.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s of %s: %w", fieldName(f), name, err)
+			}
+			fields[i] = types.NewField(token.NoPos, nil, "_", typ, false)
+		}
+		return types.NewStruct(fields, nil), nil
+	}
+	for _, i := range pkg.Interfaces {
+		if i.Name == name {
+			return emptyInterface, nil
+		}
+	}
+	for _, t := range pkg.Types {
+		if t.Name == name && t.Underlying != "" {
+			return l.typeString(p, t.Underlying)
+		}
+	}
+	return nil, nil
+}
+
+func fieldName(f Field) string {
+	if f.Name == "" {
+		return embeddedTypeName(f)
+	}
+	return f.Name
+}
+
+// typeString returns the type of a type expression of package p, as spelled in Field.Type.
+func (l *layouter) typeString(p int, typ string) (types.Type, error) {
+	switch typ {
+	case "/*func*/":
+		return types.Typ[types.UnsafePointer], nil
+	case "/*struct*/":
+		return nil, errors.New("anonymous struct types are not supported")
+	}
+	expr, err := parser.ParseExpr(typ)
+	if err != nil {
+		return nil, err
+	}
+	return l.typeExpr(p, expr)
+}
+
+func (l *layouter) typeExpr(p int, expr ast.Expr) (types.Type, error) {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if p >= 0 {
+			if typ, err := l.declared(p, e.Name); typ != nil || err != nil {
+				return typ, err
+			}
+		}
+		if obj, ok := types.Universe.Lookup(e.Name).(*types.TypeName); ok {
+			return obj.Type(), nil
+		}
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			return l.qualified(p, x.Name, e.Sel.Name)
+		}
+	case *ast.StarExpr, *ast.FuncType, *ast.MapType, *ast.ChanType:
+		// Pointers, functions, maps and channels are all a single pointer
+		return types.Typ[types.UnsafePointer], nil
+	case *ast.InterfaceType:
+		return emptyInterface, nil
+	case *ast.ArrayType:
+		if e.Len == nil {
+			// The element type does not change the size of a slice
+			return types.NewSlice(types.Typ[types.Byte]), nil
+		}
+		elem, err := l.typeExpr(p, e.Elt)
+		if err != nil {
+			return nil, err
+		}
+		lit, ok := e.Len.(*ast.BasicLit)
+		if !ok {
+			break
+		}
+		n, err := strconv.ParseInt(lit.Value, 0, 64)
+		if err != nil {
+			return nil, err
+		}
+		return types.NewArray(elem, n), nil
+	case *ast.StructType:
+		var fields []*types.Var
+		for _, f := range e.Fields.List {
+			typ, err := l.typeExpr(p, f.Type)
+			if err != nil {
+				return nil, err
+			}
+			for range f.Names {
+				fields = append(fields, types.NewField(token.NoPos, nil, "_", typ, false))
+			}
+		}
+		return types.NewStruct(fields, nil), nil
+	}
+	return nil, fmt.Errorf("unknown size of %s", types.ExprString(expr))
+}
+
+// qualified returns the type name of the package imported as pkgName by package p: a
+// package of the Output, preferring one p imports when several have that name, or else a
+// type of knownLayouts for the import path of pkgName.
+func (l *layouter) qualified(p int, pkgName, name string) (types.Type, error) {
+	candidates := l.packages[pkgName]
+	if len(candidates) > 1 && p >= 0 {
+	imported:
+		for _, c := range candidates {
+			for _, path := range l.out.Packages[p].Imports {
+				if path == l.out.Packages[c].Path {
+					candidates = []int{c}
+					break imported
+				}
+			}
+		}
+	}
+	if len(candidates) == 1 {
+		if typ, err := l.declared(candidates[0], name); typ != nil || err != nil {
+			return typ, err
+		}
+	}
+	path := pkgName
+	if p >= 0 {
+		path = importPathOf(l.out.Packages[p], pkgName)
+	}
+	if typ, err := l.declared(-1, path+"."+name); typ != nil || err != nil {
+		return typ, err
+	}
+	return nil, fmt.Errorf("unknown size of %s.%s", pkgName, name)
+}
@@ -0,0 +1,171 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var layoutSources = map[string]string{
+	"shop/order.go": `package shop
+
+import (
+	"sync"
+	"time"
+
+	"example.com/shop/models"
+	"example.com/vendor/money"
+)
+
+// Order wastes 14 bytes of padding on 64-bit platforms
+type Order struct {
+	Paid  bool
+	ID    int64
+	Draft bool
+}
+
+type Packed struct {
+	ID          int64
+	Paid, Draft bool
+}
+
+type Line struct {
+	Mu      sync.Mutex
+	Updated time.Time
+	Owner   models.User
+	Tags    []string
+	Next    *Line
+	Done    bool
+}
+
+type Price struct {
+	Amount money.Amount
+}
+`,
+	"models/user.go": `package models
+
+type Role uint8
+
+type User struct {
+	Name string
+	Role Role
+}
+`,
+}
+
+func TestComputeLayout(t *testing.T) {
+	output, err := ParseOptions{Layout: true, GOARCH: "amd64"}.ParseSources(layoutSources)
+	require.NoError(t, err)
+	x := NewIndex(output)
+
+	order := mustStruct(t, x, "Order")
+	require.Equal(t, int64(24), order.Size)
+	require.Equal(t, int64(8), order.Align)
+	require.Equal(t, []int64{0, 8, 16}, []int64{order.Fields[0].Offset, order.Fields[1].Offset, order.Fields[2].Offset})
+	require.Equal(t, int64(8), order.Fields[1].Size)
+
+	packed := mustStruct(t, x, "Packed")
+	require.Len(t, packed.Fields, 3)
+	require.Equal(t, int64(16), packed.Size)
+	require.Equal(t, int64(9), packed.Fields[2].Offset)
+
+	// Types of other parsed packages and common library types are sized
+	line := mustStruct(t, x, "Line")
+	require.Equal(t, int64(8+24+24+24+8+8), line.Size)
+	require.Equal(t, int64(32), line.Fields[2].Offset)
+
+	// money is not parsed, so the layout of Price is unknown
+	require.Zero(t, mustStruct(t, x, "Price").Size)
+
+	// int64 is only 4-byte aligned on 386
+	output, err = ParseOptions{Layout: true, GOARCH: "386"}.ParseSources(layoutSources)
+	require.NoError(t, err)
+	order = mustStruct(t, NewIndex(output), "Order")
+	require.Equal(t, int64(16), order.Size)
+	require.Equal(t, int64(4), order.Fields[1].Offset)
+
+	_, err = ParseOptions{Layout: true, GOARCH: "nosuch"}.ParseSources(layoutSources)
+	require.Error(t, err)
+}
+
+func TestAnalyzeLayout(t *testing.T) {
+	output, err := ParseSources(layoutSources)
+	require.NoError(t, err)
+	layouts, err := AnalyzeLayout(output, "amd64")
+	require.NoError(t, err)
+	byName := map[string]StructLayout{}
+	for _, l := range layouts {
+		byName[l.Name] = l
+	}
+
+	order := byName["Order"]
+	require.Equal(t, int64(24), order.Size)
+	require.Equal(t, int64(14), order.Padding)
+	require.Equal(t, int64(16), order.OptimalSize)
+	require.Equal(t, []string{"ID", "Paid", "Draft"}, order.OptimalOrder)
+
+	packed := byName["Packed"]
+	require.Equal(t, int64(6), packed.Padding)
+	require.Equal(t, packed.Size, packed.OptimalSize)
+	require.Nil(t, packed.OptimalOrder)
+
+	line := byName["Line"]
+	require.Equal(t, int64(7), line.Padding)
+	require.Nil(t, line.OptimalOrder)
+
+	require.Equal(t, "field Amount of Price: unknown size of money.Amount", byName["Price"].Unresolved)
+	require.Zero(t, byName["Price"].Size)
+
+	_, err = AnalyzeLayout(output, "nosuch")
+	require.Error(t, err)
+}
+
+func TestLayoutKnownTypes(t *testing.T) {
+	output, err := ParseSources(map[string]string{
+		"stats/stats.go": `package stats
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+type Stats struct {
+	Ready bool
+	Hits  atomic.Int64
+	Wg    sync.WaitGroup
+}
+`,
+		"counters/counters.go": `package counters
+
+import "go.uber.org/atomic"
+
+// Counter uses an atomic package other than sync/atomic
+type Counter struct {
+	Hits atomic.Int64
+}
+`,
+	})
+	require.NoError(t, err)
+
+	byName := func(goarch string) map[string]StructLayout {
+		layouts, err := AnalyzeLayout(output, goarch)
+		require.NoError(t, err)
+		m := map[string]StructLayout{}
+		for _, l := range layouts {
+			m[l.Name] = l
+		}
+		return m
+	}
+
+	// uint64 is 8-byte aligned on amd64, like atomic.Int64
+	stats := byName("amd64")["Stats"]
+	require.Empty(t, stats.Unresolved)
+	require.Equal(t, int64(8+8+16), stats.Size)
+
+	// On 386 atomic.Int64 is 8-byte aligned when uint64 is not, which is not modelled
+	layouts := byName("386")
+	require.Equal(t, "field Hits of Stats: unknown layout of sync/atomic.Int64, which is 8-byte aligned on every architecture", layouts["Stats"].Unresolved)
+
+	// Known types are looked up by import path, not by package name
+	require.Equal(t, "field Hits of Counter: unknown size of atomic.Int64", layouts["Counter"].Unresolved)
+}
@@ -0,0 +1,280 @@
+package structparser
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"sort"
+	"strings"
+)
+
+// fileDecls are the declarations of one file. They depend on nothing but the file, so
+// the cache stores them by file content; linkPackages puts the files of a package
+// together.
+type fileDecls struct {
+	File      string      `json:"file"`
+	Package   string      `json:"package"`
+	Imports   []string    `json:"imports,omitempty"`
+	Types     []typeDecl  `json:"types,omitempty"`
+	Methods   []funcDecl  `json:"methods,omitempty"`
+	Functions []funcDecl  `json:"functions,omitempty"`
+	Constants []constDecl `json:"constants,omitempty"`
+	Variables []Variable  `json:"variables,omitempty"`
+}
+
+// typeDecl is a type declaration, with the struct or interface it declares.
+type typeDecl struct {
+	Type      Type           `json:"type"`
+	Docs      []string       `json:"docs,omitempty"`
+	Struct    *Struct        `json:"struct,omitempty"`
+	Interface *Interface     `json:"interface,omitempty"`
+	Embedded  []embeddedDecl `json:"embedded,omitempty"` // Embedded fields of a struct naming a type of the package
+}
+
+// embeddedDecl is an embedded field naming a type of the package.
+type embeddedDecl struct {
+	Name    string `json:"name"`
+	Pointer bool   `json:"pointer,omitempty"`
+}
+
+// funcDecl is a function, or a method when RecvType is set.
+type funcDecl struct {
+	Method
+	RecvType   string `json:"recvType,omitempty"`   // Base name of the receiver type
+	Documented bool   `json:"documented,omitempty"` // The declaration has a doc comment
+	Test       *Test  `json:"test,omitempty"`       // Set for the test functions of _test.go files
+}
+
+// constDecl is a constant with the expression its value is evaluated from.
+type constDecl struct {
+	Constant
+	Expr string `json:"expr,omitempty"` // Value expression, repeated from a previous spec when omitted
+	Iota int    `json:"iota,omitempty"`
+}
+
+// linkPackages builds an Output from the declarations of files grouped by package name.
+func linkPackages(files map[string][]fileDecls) *Output {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	output := &Output{
+		SchemaVersion: SchemaVersion,
+		Packages:      make([]Package, 0, len(names)),
+	}
+	for _, name := range names {
+		output.Packages = append(output.Packages, linkPackage(name, files[name]))
+	}
+	return output
+}
+
+// linkPackage puts the declarations of the files of a package together the way go/doc
+// does: files are read in name order, a type declared twice keeps its last declaration,
+// a function or method declared twice keeps the first documented one or else the last,
+// and structs list their own methods and those promoted from embedded types.
+func linkPackage(name string, files []fileDecls) Package {
+	sort.SliceStable(files, func(i, j int) bool { return files[i].File < files[j].File })
+
+	outPkg := Package{
+		Package:   name,
+		Structs:   make([]Struct, 0),
+		Functions: make([]Function, 0),
+		Variables: make([]Variable, 0),
+		Constants: make([]Constant, 0),
+		Imports:   make([]string, 0),
+	}
+
+	types := map[string]typeDecl{}
+	embedded := map[string]map[string]bool{}
+	methods := map[string]map[string]funcDecl{}
+	functions := map[string]funcDecl{}
+	imports := map[string]bool{}
+	constValues := map[string]constant.Value{}
+	for _, file := range files {
+		for _, path := range file.Imports {
+			imports[path] = true
+		}
+		for _, t := range file.Types {
+			types[t.Type.Name] = t
+			for _, e := range t.Embedded {
+				if embedded[t.Type.Name] == nil {
+					embedded[t.Type.Name] = map[string]bool{}
+				}
+				embedded[t.Type.Name][e.Name] = e.Pointer
+			}
+		}
+		for _, m := range file.Methods {
+			if methods[m.RecvType] == nil {
+				methods[m.RecvType] = map[string]funcDecl{}
+			}
+			setFunc(methods[m.RecvType], m)
+		}
+		for _, f := range file.Functions {
+			setFunc(functions, f)
+		}
+		for _, c := range file.Constants {
+			outPkg.Constants = append(outPkg.Constants, c.Constant)
+			if c.Expr == "" {
+				continue
+			}
+			if expr, err := parser.ParseExpr(c.Expr); err == nil {
+				if v := evalConstExpr(expr, c.Iota, constValues); v.Kind() != constant.Unknown {
+					constValues[c.Name] = v
+				}
+			}
+		}
+		outPkg.Variables = append(outPkg.Variables, file.Variables...)
+	}
+
+	typeNames := make([]string, 0, len(types))
+	for typeName := range types {
+		typeNames = append(typeNames, typeName)
+	}
+	sort.Strings(typeNames)
+	sortedTypes := make([]typeDecl, 0, len(typeNames))
+	declared := map[string][]Method{}
+	for _, typeName := range typeNames {
+		t := types[typeName]
+		sortedTypes = append(sortedTypes, t)
+		for _, m := range sortedFuncs(methods[typeName]) {
+			declared[typeName] = append(declared[typeName], m.Method)
+		}
+		typ := t.Type
+		typ.Methods = declared[typeName]
+		outPkg.Types = append(outPkg.Types, typ)
+
+		if t.Struct != nil {
+			s := *t.Struct
+			for _, m := range structMethods(typeName, methods, embedded) {
+				if strings.Trim(m.Receiver, "*") == s.Name {
+					s.Methods = append(s.Methods, m)
+				}
+			}
+			outPkg.Structs = append(outPkg.Structs, s)
+		}
+		if t.Interface != nil {
+			outPkg.Interfaces = append(outPkg.Interfaces, *t.Interface)
+		}
+	}
+	computeMethodSets(&outPkg, declared)
+
+	for _, f := range sortedFuncs(functions) {
+		if f.Test != nil {
+			outPkg.Tests = append(outPkg.Tests, *f.Test)
+			continue
+		}
+		outPkg.Functions = append(outPkg.Functions, Function{
+			Position:  f.Position,
+			Name:      f.Name,
+			Params:    f.Params,
+			Returns:   f.Returns,
+			Docs:      f.Docs,
+			Signature: f.Signature,
+			Body:      f.Body,
+		})
+	}
+
+	for path := range imports {
+		outPkg.Imports = append(outPkg.Imports, path)
+	}
+	sort.Strings(outPkg.Imports)
+
+	outPkg.Enums = extractEnums(sortedTypes, outPkg.Constants, constValues)
+	return outPkg
+}
+
+// setFunc adds f to the functions or methods by name, unless one with documentation is
+// already there.
+func setFunc(set map[string]funcDecl, f funcDecl) {
+	if old, ok := set[f.Name]; ok && old.Documented {
+		return
+	}
+	set[f.Name] = f
+}
+
+func sortedFuncs(set map[string]funcDecl) []funcDecl {
+	funcs := make([]funcDecl, 0, len(set))
+	for _, f := range set {
+		funcs = append(funcs, f)
+	}
+	sort.Slice(funcs, func(i, j int) bool { return funcs[i].Name < funcs[j].Name })
+	return funcs
+}
+
+// structMethods returns the methods of the struct typeName sorted by name: its own and
+// those promoted through embedded types of the package, with their receiver rewritten to
+// the struct. As in go/doc, a method found at a shallower depth of embedding wins, and
+// one found twice at the same depth is ambiguous and left out.
+func structMethods(typeName string, methods map[string]map[string]funcDecl, embedded map[string]map[string]bool) []Method {
+	type entry struct {
+		method    Method
+		depth     int
+		ambiguous bool
+	}
+	set := map[string]entry{}
+	for _, m := range methods[typeName] {
+		set[m.Name] = entry{method: m.Method}
+	}
+
+	visited := map[string]bool{}
+	var collect func(typ string, pointer bool, depth int)
+	collect = func(typ string, pointer bool, depth int) {
+		visited[typ] = true
+		for name, embeddedPointer := range embedded[typ] {
+			// Once embedded through a pointer, methods of deeper types have a value receiver
+			viaPointer := pointer || embeddedPointer
+			for _, m := range methods[name] {
+				promoted := m.Method
+				promoted.Receiver = typeName
+				if !viaPointer && strings.HasPrefix(m.Receiver, "*") {
+					promoted.Receiver = "*" + typeName
+				}
+				old, ok := set[promoted.Name]
+				switch {
+				case !ok || depth < old.depth:
+					set[promoted.Name] = entry{method: promoted, depth: depth}
+				case depth == old.depth:
+					set[promoted.Name] = entry{depth: depth, ambiguous: true}
+				}
+			}
+			if !visited[name] {
+				collect(name, viaPointer, depth+1)
+			}
+		}
+		delete(visited, typ)
+	}
+	collect(typeName, false, 1)
+
+	list := make([]Method, 0, len(set))
+	for _, e := range set {
+		if !e.ambiguous {
+			list = append(list, e.method)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// baseTypeName returns the name of the type x refers to, without pointer or type
+// arguments, and whether it is qualified by a package name.
+func baseTypeName(x ast.Expr) (name string, imported bool) {
+	switch t := x.(type) {
+	case *ast.Ident:
+		return t.Name, false
+	case *ast.IndexExpr:
+		return baseTypeName(t.X)
+	case *ast.IndexListExpr:
+		return baseTypeName(t.X)
+	case *ast.SelectorExpr:
+		if _, ok := t.X.(*ast.Ident); ok {
+			return t.Sel.Name, true
+		}
+	case *ast.ParenExpr:
+		return baseTypeName(t.X)
+	case *ast.StarExpr:
+		return baseTypeName(t.X)
+	}
+	return "", false
+}
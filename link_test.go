@@ -0,0 +1,75 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLinkPackage(t *testing.T) {
+	output, err := ParseSources(map[string]string{
+		"base.go": `package shop
+
+type Base struct{ ID int }
+
+func (b *Base) Save() error { return nil }
+func (b Base) Key() string  { return "" }
+
+const first = 1
+`,
+		"user.go": `package shop
+
+type User struct {
+	Base
+	Email string
+}
+
+// NewUser returns a user.
+func NewUser() *User { return &User{} }
+
+func (u User) Key() string { return u.Email }
+
+type Level int
+
+const (
+	Low Level = first + iota
+	High
+)
+`,
+		"user_other.go": `package shop
+
+func (u *User) Reset() {}
+
+// Key is documented here.
+func (u User) Key() string { return "" }
+`,
+	})
+	require.NoError(t, err)
+	require.Len(t, output.Packages, 1)
+	pkg := output.Packages[0]
+
+	// Methods from every file, promoted ones with the receiver of the embedding struct
+	require.Len(t, pkg.Structs, 2)
+	user := pkg.Structs[1]
+	require.Equal(t, "User", user.Name)
+	methods := map[string]string{}
+	for _, m := range user.Methods {
+		methods[m.Name] = m.Receiver + " " + m.File
+	}
+	require.Equal(t, map[string]string{
+		"Key":   "User user_other.go",
+		"Reset": "*User user_other.go",
+		"Save":  "*User base.go",
+	}, methods)
+
+	require.Len(t, pkg.Functions, 1)
+	require.Equal(t, "NewUser", pkg.Functions[0].Name)
+
+	// Constants are evaluated across files
+	require.Len(t, pkg.Enums, 1)
+	values := []string{}
+	for _, v := range pkg.Enums[0].Values {
+		values = append(values, v.Name+"="+v.Value)
+	}
+	require.Equal(t, []string{"Low=1", "High=2"}, values)
+}
@@ -0,0 +1,242 @@
+package structparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// ParseDirectories parses package directories concurrently. See
+// ParseOptions.ParseDirectories.
+func ParseDirectories(ctx context.Context, dirs []string) (*Output, error) {
+	return ParseOptions{}.ParseDirectories(ctx, dirs)
+}
+
+// ParseDirectories parses package directories with a pool of o.Workers goroutines that
+// share one token.FileSet. Packages are merged in the order of dirs, whatever order the
+// workers finish in, so the output is the same as parsing the directories one by one.
+// Parsing stops at the first error, or when ctx is done, in which case ctx.Err() is
+// returned.
+func (o ParseOptions) ParseDirectories(ctx context.Context, dirs []string) (*Output, error) {
+	results, _, err := o.parseEach(ctx, dirs, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	output := mergeOutputs(results)
+	return output, o.layout(output)
+}
+
+// ParseContext parses the packages matched by patterns: directories, Go files, or a
+// directory followed by "/..." for it and all its subdirectories, skipping testdata,
+// vendor and directories starting with "." or "_" like the go command. No pattern means
+// the current directory.
+//
+// Packages that fail to parse do not stop the others; they are reported in
+// Output.Diagnostics. When ctx is done, ParseContext returns promptly with the packages
+// parsed so far, the diagnostics collected so far and ctx.Err().
+func ParseContext(ctx context.Context, opts ParseOptions, patterns ...string) (*Output, error) {
+	dirs, walkErrs := expandPatterns(ctx, patterns)
+	results, errs, _ := opts.parseEach(ctx, dirs, false)
+	output := mergeOutputs(results)
+	if err := opts.layout(output); err != nil {
+		return output, err
+	}
+	for _, err := range walkErrs {
+		if !errors.Is(err.err, context.Canceled) && !errors.Is(err.err, context.DeadlineExceeded) {
+			output.Diagnostics = append(output.Diagnostics, diagnostics(err.path, err.err)...)
+		}
+	}
+	for i, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			output.Diagnostics = append(output.Diagnostics, diagnostics(dirs[i], err)...)
+		}
+	}
+	return output, ctx.Err()
+}
+
+// parseEach parses dirs with a pool of workers. Results and errors are indexed like dirs;
+// directories skipped because ctx was done have neither. With failFast, the first error
+// cancels the remaining directories and is returned.
+func (o ParseOptions) parseEach(ctx context.Context, dirs []string, failFast bool) ([]*Output, []error, error) {
+	workers := o.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(dirs) {
+		workers = len(dirs)
+	}
+
+	workCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		fset    = token.NewFileSet()
+		results = make([]*Output, len(dirs))
+		errs    = make([]error, len(dirs))
+		once    sync.Once
+		failure error
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if workCtx.Err() != nil {
+					continue
+				}
+				output, err := o.parseDirectory(workCtx, fset, dirs[i])
+				if err != nil {
+					errs[i] = err
+					if failFast && workCtx.Err() == nil {
+						once.Do(func() {
+							failure = fmt.Errorf("%s: %w", dirs[i], err)
+							cancel()
+						})
+					}
+					continue
+				}
+				results[i] = output
+			}
+		}()
+	}
+feed:
+	for i := range dirs {
+		select {
+		case jobs <- i:
+		case <-workCtx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errs, failure
+}
+
+// mergeOutputs concatenates the packages of outputs in order, skipping missing ones.
+func mergeOutputs(outputs []*Output) *Output {
+	merged := &Output{SchemaVersion: SchemaVersion, Packages: []Package{}}
+	for _, output := range outputs {
+		if output != nil {
+			merged.Packages = append(merged.Packages, output.Packages...)
+		}
+	}
+	return merged
+}
+
+// diagnostics converts the error of a directory, splitting syntax errors by position.
+func diagnostics(dir string, err error) []Diagnostic {
+	var list scanner.ErrorList
+	if errors.As(err, &list) {
+		out := make([]Diagnostic, len(list))
+		for i, e := range list {
+			out[i] = Diagnostic{Message: e.Msg, Position: Position{File: e.Pos.Filename, Line: e.Pos.Line}}
+		}
+		return out
+	}
+	return []Diagnostic{{Message: err.Error(), Position: Position{File: dir}}}
+}
+
+// walkError is a directory that could not be walked while expanding a pattern.
+type walkError struct {
+	path string
+	err  error
+}
+
+// expandPatterns turns patterns into a list of directories or files, without duplicates.
+// Directories that cannot be read are reported and skipped, the rest of the walk goes on;
+// when ctx is done, the directories found so far are returned, with ctx.Err() last.
+func expandPatterns(ctx context.Context, patterns []string) ([]string, []walkError) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+	seen := map[string]bool{}
+	var (
+		dirs []string
+		errs []walkError
+	)
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, pattern := range patterns {
+		root, recursive := recursiveRoot(pattern)
+		if !recursive {
+			add(pattern)
+			continue
+		}
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err != nil {
+				errs = append(errs, walkError{path: path, err: err})
+				if d != nil && d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if !d.IsDir() {
+				return nil
+			}
+			if path != root && ignoredDir(d.Name()) {
+				return filepath.SkipDir
+			}
+			if hasGoFiles(path) {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, walkError{path: root, err: err})
+			return dirs, errs
+		}
+	}
+	return dirs, errs
+}
+
+// recursiveRoot returns the directory of a "dir/..." pattern.
+func recursiveRoot(pattern string) (root string, ok bool) {
+	if pattern != "..." && !strings.HasSuffix(pattern, "/...") {
+		return "", false
+	}
+	root = strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+	if root == "" {
+		root = "."
+	}
+	return root, true
+}
+
+// ignoredDir reports whether "dir/..." patterns skip directories with this name, like
+// the go command does.
+func ignoredDir(name string) bool {
+	return name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")
+}
+
+func hasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+			return true
+		}
+	}
+	return false
+}
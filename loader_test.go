@@ -0,0 +1,108 @@
+package structparser
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writePackages(t *testing.T, n int) []string {
+	t.Helper()
+	root := t.TempDir()
+	dirs := make([]string, n)
+	for i := range dirs {
+		dirs[i] = filepath.Join(root, fmt.Sprintf("pkg%02d", i))
+		require.NoError(t, os.Mkdir(dirs[i], 0o755))
+		source := fmt.Sprintf("package pkg%02d\n\ntype User struct {\n\tName string\n}\n\nfunc (u *User) Save() error { return nil }\n", i)
+		require.NoError(t, os.WriteFile(filepath.Join(dirs[i], "user.go"), []byte(source), 0o644))
+	}
+	return dirs
+}
+
+func TestParseDirectories(t *testing.T) {
+	dirs := writePackages(t, 20)
+
+	serial := &Output{SchemaVersion: SchemaVersion, Packages: []Package{}}
+	for _, dir := range dirs {
+		output, err := ParseDirectory(dir)
+		require.NoError(t, err)
+		serial.Packages = append(serial.Packages, output.Packages...)
+	}
+
+	for _, workers := range []int{0, 1, 4, 50} {
+		output, err := ParseOptions{Workers: workers}.ParseDirectories(context.Background(), dirs)
+		require.NoError(t, err)
+		require.Equal(t, serial, output, "workers=%d", workers)
+	}
+
+	output, err := ParseDirectories(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, output.Packages)
+}
+
+func TestParseDirectoriesErrors(t *testing.T) {
+	dirs := writePackages(t, 5)
+	missing := filepath.Join(filepath.Dir(dirs[0]), "missing")
+	_, err := ParseDirectories(context.Background(), append(dirs, missing))
+	require.ErrorContains(t, err, "missing")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ParseDirectories(ctx, dirs)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestParseContext(t *testing.T) {
+	dirs := writePackages(t, 3)
+	root := filepath.Dir(dirs[0])
+	write := func(name, content string) {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+	write("broken/broken.go", "package broken\n\nvar = 1\n")
+	write("testdata/skipped.go", "package skipped\n")
+	write(".hidden/skipped.go", "package skipped\n")
+	write("docs/README.md", "no Go files")
+
+	output, err := ParseContext(context.Background(), ParseOptions{}, root+"/...")
+	require.NoError(t, err)
+	var names []string
+	for _, pkg := range output.Packages {
+		names = append(names, pkg.Package)
+	}
+	// Packages are listed in directory order and the broken one becomes a diagnostic
+	require.Equal(t, []string{"pkg00", "pkg01", "pkg02"}, names)
+	require.NotEmpty(t, output.Diagnostics)
+	for _, d := range output.Diagnostics {
+		require.Equal(t, Position{File: filepath.Join(root, "broken", "broken.go"), Line: 3}, d.Position)
+	}
+
+	// Patterns are deduplicated
+	output, err = ParseContext(context.Background(), ParseOptions{}, dirs[1], dirs[1]+"/...", dirs[1])
+	require.NoError(t, err)
+	require.Len(t, output.Packages, 1)
+
+	output, err = ParseContext(context.Background(), ParseOptions{}, filepath.Join(root, "missing"))
+	require.NoError(t, err)
+	require.Empty(t, output.Packages)
+	require.Len(t, output.Diagnostics, 1)
+
+	// A pattern that cannot be walked does not drop the packages of the others
+	output, err = ParseContext(context.Background(), ParseOptions{}, filepath.Join(root, "missing")+"/...", dirs[0])
+	require.NoError(t, err)
+	require.Len(t, output.Packages, 1)
+	require.Len(t, output.Diagnostics, 1)
+	require.Equal(t, filepath.Join(root, "missing"), output.Diagnostics[0].File)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	output, err = ParseContext(ctx, ParseOptions{}, dirs...)
+	require.ErrorIs(t, err, context.Canceled)
+	require.NotNil(t, output)
+	require.Empty(t, output.Packages)
+}
@@ -0,0 +1,653 @@
+package structparser
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Commands accepted by workspace/executeCommand.
+const (
+	// LSPCommandImplementors takes an interface name, optionally qualified ("pkg.Name"),
+	// and returns the locations of the structs implementing it.
+	LSPCommandImplementors = "structparser.implementors"
+	// LSPCommandStructsWithTag takes a struct tag key (e.g., "db") and returns the
+	// structs having a field with that key, as SymbolInformation.
+	LSPCommandStructsWithTag = "structparser.structsWithTag"
+)
+
+// ServeLSP runs a Language Server Protocol server reading requests from in and writing
+// responses to out, as `structparser lsp` does over stdio. The workspace folders sent with
+// initialize are loaded with opts, and open documents are parsed from the editor buffers.
+//
+// Supported requests are workspace/symbol (a query of the form "tag:key" lists the structs
+// with a field tagged with key), textDocument/documentSymbol, textDocument/hover (field
+// tags are broken down by key), textDocument/codeLens (implemented interfaces and
+// implementation counts) and workspace/executeCommand with LSPCommandImplementors and
+// LSPCommandStructsWithTag.
+//
+// ServeLSP returns nil after the exit notification, io.EOF when in is closed, or ctx.Err()
+// when ctx is done.
+func ServeLSP(ctx context.Context, in io.Reader, out io.Writer, opts ParseOptions) error {
+	s := &lspServer{ws: NewWorkspace(opts), out: out}
+	messages := make(chan lspRequest)
+	readErr := make(chan error, 1)
+	go func() {
+		r := bufio.NewReader(in)
+		for {
+			req, err := readLSPMessage(r)
+			if err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case messages <- req:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-readErr:
+			return err
+		case req := <-messages:
+			if req.Method == "exit" {
+				return nil
+			}
+			if err := s.handle(ctx, req); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+type lspRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent for notifications
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type lspResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *lspError       `json:"error,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// JSON-RPC error codes.
+const (
+	lspInvalidParams  = -32602
+	lspMethodNotFound = -32601
+)
+
+// readLSPMessage reads a message framed by a Content-Length header.
+func readLSPMessage(r *bufio.Reader) (lspRequest, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return lspRequest{}, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if ok && strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			if length, err = strconv.Atoi(strings.TrimSpace(value)); err != nil {
+				return lspRequest{}, fmt.Errorf("lsp: invalid Content-Length %q", value)
+			}
+		}
+	}
+	if length < 0 {
+		return lspRequest{}, errors.New("lsp: missing Content-Length")
+	}
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return lspRequest{}, err
+	}
+	var req lspRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return lspRequest{}, fmt.Errorf("lsp: %w", err)
+	}
+	return req, nil
+}
+
+type lspServer struct {
+	ws *Workspace
+
+	mu  sync.Mutex // Serializes writes to out
+	out io.Writer
+}
+
+func (s *lspServer) write(v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return err
+	}
+	_, err = s.out.Write(body)
+	return err
+}
+
+// handle answers a request, or applies a notification. Only write errors are returned.
+func (s *lspServer) handle(ctx context.Context, req lspRequest) error {
+	result, rpcErr := s.dispatch(ctx, req)
+	if len(req.ID) == 0 {
+		return nil
+	}
+	resp := lspResponse{JSONRPC: "2.0", ID: req.ID, Error: rpcErr}
+	if rpcErr == nil {
+		body, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = body
+	}
+	return s.write(resp)
+}
+
+func (s *lspServer) dispatch(ctx context.Context, req lspRequest) (interface{}, *lspError) {
+	var params struct {
+		RootURI          string `json:"rootUri"`
+		RootPath         string `json:"rootPath"`
+		WorkspaceFolders []struct {
+			URI string `json:"uri"`
+		} `json:"workspaceFolders"`
+		TextDocument struct {
+			URI  string `json:"uri"`
+			Text string `json:"text"`
+		} `json:"textDocument"`
+		ContentChanges []struct {
+			Text string `json:"text"`
+		} `json:"contentChanges"`
+		Position  lspPosition `json:"position"`
+		Query     string      `json:"query"`
+		Command   string      `json:"command"`
+		Arguments []string    `json:"arguments"`
+	}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &lspError{Code: lspInvalidParams, Message: err.Error()}
+		}
+	}
+	path := uriToPath(params.TextDocument.URI)
+
+	switch req.Method {
+	case "initialize":
+		var roots []string
+		for _, folder := range params.WorkspaceFolders {
+			roots = append(roots, uriToPath(folder.URI)+"/...")
+		}
+		if len(roots) == 0 && params.RootURI != "" {
+			roots = append(roots, uriToPath(params.RootURI)+"/...")
+		}
+		if len(roots) == 0 && params.RootPath != "" {
+			roots = append(roots, params.RootPath+"/...")
+		}
+		if len(roots) > 0 {
+			// Packages that do not parse are simply missing until they are fixed
+			s.ws.Load(ctx, roots...)
+		}
+		return map[string]interface{}{
+			"capabilities": map[string]interface{}{
+				"textDocumentSync":        map[string]interface{}{"openClose": true, "change": 1},
+				"workspaceSymbolProvider": true,
+				"documentSymbolProvider":  true,
+				"hoverProvider":           true,
+				"codeLensProvider":        map[string]interface{}{"resolveProvider": false},
+				"executeCommandProvider": map[string]interface{}{
+					"commands": []string{LSPCommandImplementors, LSPCommandStructsWithTag},
+				},
+			},
+			"serverInfo": map[string]string{"name": "structparser"},
+		}, nil
+	case "initialized", "workspace/didChangeConfiguration", "textDocument/didSave":
+		return nil, nil
+	case "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		s.ws.UpdateFile(path, []byte(params.TextDocument.Text))
+		return nil, nil
+	case "textDocument/didChange":
+		// Full document sync: the last change holds the whole text
+		if n := len(params.ContentChanges); n > 0 {
+			s.ws.UpdateFile(path, []byte(params.ContentChanges[n-1].Text))
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		// Back to the content on disk
+		if content, err := os.ReadFile(path); err == nil {
+			s.ws.UpdateFile(path, content)
+		} else {
+			s.ws.RemoveFile(path)
+		}
+		return nil, nil
+	case "workspace/symbol":
+		return s.workspaceSymbols(params.Query), nil
+	case "textDocument/documentSymbol":
+		return s.documentSymbols(path), nil
+	case "textDocument/hover":
+		return s.hover(path, params.Position.Line+1), nil
+	case "textDocument/codeLens":
+		return s.codeLenses(path), nil
+	case "workspace/executeCommand":
+		if len(params.Arguments) != 1 {
+			return nil, &lspError{Code: lspInvalidParams, Message: params.Command + " takes one argument"}
+		}
+		switch params.Command {
+		case LSPCommandImplementors:
+			locations := []lspLocation{}
+			implementors, _ := findImplementors(s.ws.Output(), params.Arguments[0])
+			for _, impl := range implementors {
+				locations = append(locations, location(impl.Position))
+			}
+			return locations, nil
+		case LSPCommandStructsWithTag:
+			return s.workspaceSymbols("tag:" + params.Arguments[0]), nil
+		}
+		return nil, &lspError{Code: lspInvalidParams, Message: "unknown command " + params.Command}
+	}
+	return nil, &lspError{Code: lspMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`      // 0-based
+	Character int `json:"character"` // 0-based, in UTF-16 code units
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+// Symbol kinds of the protocol.
+const (
+	symbolMethod     = 6
+	symbolField      = 8
+	symbolEnum       = 10
+	symbolInterface  = 11
+	symbolFunction   = 12
+	symbolVariable   = 13
+	symbolConstant   = 14
+	symbolEnumMember = 22
+	symbolStruct     = 23
+)
+
+type symbolInformation struct {
+	Name          string      `json:"name"`
+	Kind          int         `json:"kind"`
+	Location      lspLocation `json:"location"`
+	ContainerName string      `json:"containerName,omitempty"`
+}
+
+type documentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          lspRange         `json:"range"`
+	SelectionRange lspRange         `json:"selectionRange"`
+	Children       []documentSymbol `json:"children,omitempty"`
+}
+
+type lspCommand struct {
+	Title     string   `json:"title"`
+	Command   string   `json:"command"`
+	Arguments []string `json:"arguments,omitempty"`
+}
+
+type codeLens struct {
+	Range   lspRange    `json:"range"`
+	Command *lspCommand `json:"command,omitempty"`
+}
+
+// lineRange covers the start of the line of pos; entities do not record where they end.
+func lineRange(pos Position) lspRange {
+	start := lspPosition{Line: pos.Line - 1}
+	if start.Line < 0 {
+		start.Line = 0
+	}
+	return lspRange{Start: start, End: start}
+}
+
+func location(pos Position) lspLocation {
+	return lspLocation{URI: pathToURI(pos.File), Range: lineRange(pos)}
+}
+
+func uriToPath(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+	return filepath.Clean(filepath.FromSlash(u.Path))
+}
+
+func pathToURI(path string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// workspaceSymbols finds the entities whose name contains query, ignoring case, or with
+// "tag:key" the structs having a field tagged with key.
+func (s *lspServer) workspaceSymbols(query string) []symbolInformation {
+	symbols := []symbolInformation{}
+	if key, ok := cutPrefix(query, "tag:"); ok {
+		for _, pkg := range s.ws.Output().Packages {
+			for _, st := range pkg.Structs {
+				if structHasTagKey(st, key) {
+					symbols = append(symbols, symbolInformation{Name: st.Name, Kind: symbolStruct, Location: location(st.Position), ContainerName: pkg.Package})
+				}
+			}
+		}
+		return symbols
+	}
+
+	query = strings.ToLower(query)
+	add := func(name string, kind int, pos Position, container string) {
+		if strings.Contains(strings.ToLower(name), query) {
+			symbols = append(symbols, symbolInformation{Name: name, Kind: kind, Location: location(pos), ContainerName: container})
+		}
+	}
+	for _, pkg := range s.ws.Output().Packages {
+		for _, st := range pkg.Structs {
+			add(st.Name, symbolStruct, st.Position, pkg.Package)
+			for _, f := range st.Fields {
+				if f.Name != "" {
+					add(f.Name, symbolField, f.Position, st.Name)
+				}
+			}
+			for _, m := range st.Methods {
+				add(m.Name, symbolMethod, m.Position, st.Name)
+			}
+		}
+		for _, i := range pkg.Interfaces {
+			add(i.Name, symbolInterface, i.Position, pkg.Package)
+		}
+		for _, f := range pkg.Functions {
+			add(f.Name, symbolFunction, f.Position, pkg.Package)
+		}
+		for _, v := range pkg.Variables {
+			add(v.Name, symbolVariable, v.Position, pkg.Package)
+		}
+		for _, c := range pkg.Constants {
+			add(c.Name, symbolConstant, c.Position, pkg.Package)
+		}
+		for _, e := range pkg.Enums {
+			add(e.Name, symbolEnum, e.Position, pkg.Package)
+		}
+	}
+	return symbols
+}
+
+// cutPrefix is strings.CutPrefix, which needs Go 1.20.
+func cutPrefix(s, prefix string) (string, bool) {
+	if !strings.HasPrefix(s, prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
+
+func structHasTagKey(s Struct, key string) bool {
+	for _, f := range s.Fields {
+		for _, k := range tagKeys(f.Tag) {
+			if k == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// documentSymbols lists the entities declared in path, ordered by line.
+func (s *lspServer) documentSymbols(path string) []documentSymbol {
+	symbols := []documentSymbol{}
+	symbol := func(name, detail string, kind int, pos Position) documentSymbol {
+		return documentSymbol{Name: name, Detail: detail, Kind: kind, Range: lineRange(pos), SelectionRange: lineRange(pos)}
+	}
+	add := func(sym documentSymbol) {
+		symbols = append(symbols, sym)
+	}
+	for _, pkg := range s.ws.Output().Packages {
+		for _, st := range pkg.Structs {
+			if st.File != path {
+				continue
+			}
+			sym := symbol(st.Name, "struct", symbolStruct, st.Position)
+			for _, f := range st.Fields {
+				name := f.Name
+				if name == "" {
+					name = embeddedTypeName(f)
+				}
+				sym.Children = append(sym.Children, symbol(name, f.Type, symbolField, f.Position))
+			}
+			for _, m := range st.Methods {
+				if m.File == path {
+					sym.Children = append(sym.Children, symbol(m.Name, m.Signature, symbolMethod, m.Position))
+				}
+			}
+			add(sym)
+		}
+		for _, st := range pkg.Structs {
+			// Methods declared in another file than their struct
+			if st.File == path {
+				continue
+			}
+			for _, m := range st.Methods {
+				if m.File == path {
+					add(symbol(st.Name+"."+m.Name, m.Signature, symbolMethod, m.Position))
+				}
+			}
+		}
+		for _, i := range pkg.Interfaces {
+			if i.File != path {
+				continue
+			}
+			sym := symbol(i.Name, "interface", symbolInterface, i.Position)
+			for _, m := range i.Methods {
+				sym.Children = append(sym.Children, symbol(m.Name, m.Signature, symbolMethod, m.Position))
+			}
+			add(sym)
+		}
+		for _, f := range pkg.Functions {
+			if f.File == path {
+				add(symbol(f.Name, f.Signature, symbolFunction, f.Position))
+			}
+		}
+		for _, v := range pkg.Variables {
+			if v.File == path {
+				add(symbol(v.Name, v.Type, symbolVariable, v.Position))
+			}
+		}
+		for _, c := range pkg.Constants {
+			if c.File == path {
+				add(symbol(c.Name, c.Value, symbolConstant, c.Position))
+			}
+		}
+		for _, e := range pkg.Enums {
+			if e.File != path {
+				continue
+			}
+			sym := symbol(e.Name, e.Type, symbolEnum, e.Position)
+			for _, v := range e.Values {
+				sym.Children = append(sym.Children, symbol(v.Name, v.Value, symbolEnumMember, v.Position))
+			}
+			add(sym)
+		}
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return symbols[i].Range.Start.Line < symbols[j].Range.Start.Line
+	})
+	return symbols
+}
+
+// hover describes the entity declared on line of path. Fields show their tag broken down
+// by key.
+func (s *lspServer) hover(path string, line int) interface{} {
+	at := func(pos Position) bool { return pos.File == path && pos.Line == line }
+	text := ""
+	var pos Position
+	output := s.ws.Output()
+	for _, pkg := range output.Packages {
+		for _, st := range pkg.Structs {
+			for _, f := range st.Fields {
+				if at(f.Position) && text == "" {
+					text, pos = fieldHover(st, f), f.Position
+				}
+			}
+			for _, m := range st.Methods {
+				if at(m.Position) && text == "" {
+					text, pos = codeHover("func ("+m.Receiver+") "+m.Signature, m.Docs), m.Position
+				}
+			}
+			if at(st.Position) && text == "" {
+				text, pos = s.structHover(output, pkg, st), st.Position
+			}
+		}
+		for _, i := range pkg.Interfaces {
+			for _, m := range i.Methods {
+				if at(m.Position) && text == "" {
+					text, pos = codeHover(i.Name+"."+m.Signature, m.Docs), m.Position
+				}
+			}
+			if at(i.Position) && text == "" {
+				implementors, _ := findImplementors(output, pkg.Package+"."+i.Name)
+				text, pos = codeHover("type "+i.Name+" interface", i.Docs), i.Position
+				text += fmt.Sprintf("\n\n%d implementation(s)", len(implementors))
+			}
+		}
+		for _, f := range pkg.Functions {
+			if at(f.Position) && text == "" {
+				text, pos = codeHover("func "+f.Signature, f.Docs), f.Position
+			}
+		}
+		for _, e := range pkg.Enums {
+			for _, v := range e.Values {
+				if at(v.Position) && text == "" {
+					text, pos = codeHover(v.Name+" "+e.Name+" = "+v.Value, v.Docs), v.Position
+				}
+			}
+		}
+	}
+	if text == "" {
+		return nil
+	}
+	return map[string]interface{}{
+		"contents": map[string]string{"kind": "markdown", "value": text},
+		"range":    lineRange(pos),
+	}
+}
+
+func codeHover(code string, docs []string) string {
+	text := "```go\n" + code + "\n```"
+	if doc := strings.TrimSpace(strings.Join(docs, "\n")); doc != "" {
+		text += "\n\n" + doc
+	}
+	return text
+}
+
+func fieldHover(st Struct, f Field) string {
+	code := st.Name + "." + f.Name + " " + f.Type
+	if f.Name == "" {
+		code = st.Name + " embeds " + f.Type
+	}
+	if f.Tag != "" {
+		code += " `" + f.Tag + "`"
+	}
+	text := codeHover(code, f.Docs)
+	if f.Comment != "" {
+		text += "\n\n" + f.Comment
+	}
+	if keys := tagKeys(f.Tag); len(keys) > 0 {
+		text += "\n\n| tag | name | options |\n| --- | --- | --- |"
+		for _, key := range keys {
+			name, options, _ := lookupTag(f.Tag, key)
+			text += fmt.Sprintf("\n| %s | %s | %s |", key, name, strings.Join(options, ", "))
+		}
+	}
+	return text
+}
+
+func (s *lspServer) structHover(output *Output, pkg Package, st Struct) string {
+	text := codeHover(fmt.Sprintf("type %s struct // %d fields, %d methods", st.Name, len(st.Fields), len(st.Methods)), st.Docs)
+	if names := implementedInterfaces(output, pkg, st); len(names) > 0 {
+		text += "\n\nImplements " + strings.Join(names, ", ")
+	}
+	return text
+}
+
+// implementedInterfaces returns the qualified names of the non-empty interfaces st of
+// pkg implements.
+func implementedInterfaces(output *Output, pkg Package, st Struct) []string {
+	var names []string
+	types := newTypeTable(output)
+	for _, ipkg := range output.Packages {
+		for _, i := range ipkg.Interfaces {
+			if types.hasMethods(ipkg, i) && types.implements(pkg, st, ipkg, i) {
+				names = append(names, ipkg.Package+"."+i.Name)
+			}
+		}
+	}
+	return names
+}
+
+// codeLenses shows above structs the interfaces they implement, and above interfaces
+// how many structs implement them.
+func (s *lspServer) codeLenses(path string) []codeLens {
+	lenses := []codeLens{}
+	output := s.ws.Output()
+	types := newTypeTable(output)
+	for _, pkg := range output.Packages {
+		for _, st := range pkg.Structs {
+			if st.File != path {
+				continue
+			}
+			if names := implementedInterfaces(output, pkg, st); len(names) > 0 {
+				lenses = append(lenses, codeLens{Range: lineRange(st.Position), Command: &lspCommand{Title: "implements " + strings.Join(names, ", ")}})
+			}
+		}
+		for _, i := range pkg.Interfaces {
+			if i.File != path || !types.hasMethods(pkg, i) {
+				continue
+			}
+			name := pkg.Package + "." + i.Name
+			implementors, _ := findImplementors(output, name)
+			lenses = append(lenses, codeLens{Range: lineRange(i.Position), Command: &lspCommand{
+				Title:     fmt.Sprintf("%d implementation(s)", len(implementors)),
+				Command:   LSPCommandImplementors,
+				Arguments: []string{name},
+			}})
+		}
+	}
+	sort.SliceStable(lenses, func(i, j int) bool {
+		return lenses[i].Range.Start.Line < lenses[j].Range.Start.Line
+	})
+	return lenses
+}
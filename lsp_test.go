@@ -0,0 +1,168 @@
+package structparser
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type lspClient struct {
+	t      *testing.T
+	w      io.Writer
+	r      *bufio.Reader
+	nextID int
+}
+
+func (c *lspClient) send(method string, id int, params interface{}) {
+	msg := map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+	if id > 0 {
+		msg["id"] = id
+	}
+	body, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	require.NoError(c.t, err)
+}
+
+// call sends a request and decodes its result into result, returning the error response.
+func (c *lspClient) call(method string, params, result interface{}) *lspError {
+	c.nextID++
+	c.send(method, c.nextID, params)
+
+	header, err := textproto.NewReader(c.r).ReadMIMEHeader()
+	require.NoError(c.t, err)
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	require.NoError(c.t, err)
+	body := make([]byte, length)
+	_, err = io.ReadFull(c.r, body)
+	require.NoError(c.t, err)
+
+	var resp struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *lspError       `json:"error"`
+	}
+	require.NoError(c.t, json.Unmarshal(body, &resp))
+	require.Equal(c.t, c.nextID, resp.ID)
+	if resp.Error == nil && result != nil {
+		require.NoError(c.t, json.Unmarshal(resp.Result, result))
+	}
+	return resp.Error
+}
+
+func TestServeLSP(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, "store.go")
+	require.NoError(t, os.WriteFile(store, []byte(`package store
+
+// Saver saves
+type Saver interface {
+	Save() error
+}
+
+// User is a user
+type User struct {
+	ID   int    `+"`json:\"id,omitempty\" db:\"user_id\"`"+`
+	Name string
+}
+
+func (u *User) Save() error { return nil }
+
+type Order struct {
+	Total int `+"`json:\"total\"`"+`
+}
+`), 0o644))
+
+	clientIn, serverOut := io.Pipe()
+	serverIn, clientOut := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- ServeLSP(context.Background(), serverIn, serverOut, ParseOptions{}) }()
+	c := &lspClient{t: t, w: clientOut, r: bufio.NewReader(clientIn)}
+
+	var init struct {
+		Capabilities map[string]interface{} `json:"capabilities"`
+	}
+	require.Nil(t, c.call("initialize", map[string]interface{}{"rootUri": pathToURI(dir)}, &init))
+	require.Equal(t, true, init.Capabilities["hoverProvider"])
+	c.send("initialized", 0, map[string]interface{}{})
+
+	var symbols []symbolInformation
+	require.Nil(t, c.call("workspace/symbol", map[string]string{"query": "tag:db"}, &symbols))
+	require.Len(t, symbols, 1)
+	require.Equal(t, "User", symbols[0].Name)
+	require.Equal(t, lspLocation{URI: pathToURI(store), Range: lspRange{Start: lspPosition{Line: 8}, End: lspPosition{Line: 8}}}, symbols[0].Location)
+
+	require.Nil(t, c.call("workspace/symbol", map[string]string{"query": "save"}, &symbols))
+	require.Len(t, symbols, 2) // Saver and User.Save
+
+	doc := map[string]interface{}{"textDocument": map[string]string{"uri": pathToURI(store)}}
+	var outline []documentSymbol
+	require.Nil(t, c.call("textDocument/documentSymbol", doc, &outline))
+	var names []string
+	for _, s := range outline {
+		names = append(names, s.Name)
+	}
+	require.Equal(t, []string{"Saver", "User", "Order"}, names)
+	require.Len(t, outline[1].Children, 3) // ID, Name and Save
+
+	var hover struct {
+		Contents struct {
+			Value string `json:"value"`
+		} `json:"contents"`
+	}
+	require.Nil(t, c.call("textDocument/hover", map[string]interface{}{
+		"textDocument": map[string]string{"uri": pathToURI(store)},
+		"position":     lspPosition{Line: 9, Character: 2},
+	}, &hover))
+	require.Contains(t, hover.Contents.Value, "| json | id | omitempty |")
+	require.Contains(t, hover.Contents.Value, "| db | user_id |  |")
+
+	var lenses []codeLens
+	require.Nil(t, c.call("textDocument/codeLens", doc, &lenses))
+	require.Len(t, lenses, 2)
+	require.Equal(t, "1 implementation(s)", lenses[0].Command.Title)
+	require.Equal(t, "implements store.Saver", lenses[1].Command.Title)
+
+	var locations []lspLocation
+	require.Nil(t, c.call("workspace/executeCommand", map[string]interface{}{
+		"command": LSPCommandImplementors, "arguments": []string{"store.Saver"},
+	}, &locations))
+	require.Len(t, locations, 1)
+
+	// Open buffers take precedence over the files on disk
+	c.send("textDocument/didOpen", 0, map[string]interface{}{"textDocument": map[string]string{
+		"uri": pathToURI(store), "text": "package store\n\ntype Order struct {\n\tTotal int `db:\"total\"`\n}\n",
+	}})
+	require.Nil(t, c.call("workspace/executeCommand", map[string]interface{}{
+		"command": LSPCommandStructsWithTag, "arguments": []string{"db"},
+	}, &symbols))
+	require.Len(t, symbols, 1)
+	require.Equal(t, "Order", symbols[0].Name)
+
+	c.send("textDocument/didClose", 0, doc)
+	require.Nil(t, c.call("workspace/symbol", map[string]string{"query": "tag:db"}, &symbols))
+	require.Equal(t, "User", symbols[0].Name)
+
+	rpcErr := c.call("textDocument/unknown", nil, nil)
+	require.NotNil(t, rpcErr)
+	require.Equal(t, lspMethodNotFound, rpcErr.Code)
+
+	require.Nil(t, c.call("shutdown", nil, nil))
+	c.send("exit", 0, nil)
+	require.NoError(t, <-done)
+}
+
+func TestTagKeys(t *testing.T) {
+	require.Equal(t, []string{"json", "db"}, tagKeys(`json:"id,omitempty" db:"user_id"`))
+	require.Equal(t, []string{"a"}, tagKeys(`a:"x\"y" broken`))
+	require.Empty(t, tagKeys(""))
+}
@@ -0,0 +1,382 @@
+package structparser
+
+import (
+	"go/ast"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// Type is a named type with its method sets, following the Go spec: a type's own methods
+// plus those promoted through embedded fields, and for interfaces their explicit methods
+// plus those of embedded interfaces. Types from other packages are not parsed, so methods
+// they would contribute are missing and Incomplete is set.
+type Type struct {
+	Name             string           `json:"name"`
+	Kind             string           `json:"kind"`                       // "struct", "interface", "alias" or "named"
+	Underlying       string           `json:"underlying,omitempty"`       // Type expression of aliases and named types (e.g., "int", "Base")
+	Methods          []Method         `json:"methods,omitempty"`          // Methods declared with the type as receiver, value or pointer
+	MethodSet        []MethodSetEntry `json:"methodSet,omitempty"`        // Methods of values of the type
+	PointerMethodSet []MethodSetEntry `json:"pointerMethodSet,omitempty"` // Methods of pointers to the type; always empty for interfaces
+	Incomplete       bool             `json:"incomplete,omitempty"`       // Method sets lack methods of types declared in other packages
+	Position
+}
+
+// MethodSetEntry is a method of a method set.
+type MethodSetEntry struct {
+	Name      string   `json:"name"`
+	Signature string   `json:"signature"`     // Parameter and result types (e.g., "func(string) error")
+	Receiver  string   `json:"receiver"`      // Receiver of the declaration (e.g., "*Base"), or the interface declaring it
+	Via       []string `json:"via,omitempty"` // Embedded fields and interfaces the method is promoted through, outermost first
+}
+
+// Implements reports whether the method set of t, declared in package tpkg, or of *t when
+// pointer is set, contains every method of the interface type i of package ipkg with
+// identical parameter and result types. Type names in signatures are qualified by their
+// package before comparing, so a User of one package does not match a User of another.
+// known is false when the answer depends on methods of types from other packages: i is
+// Incomplete, or a method it requires is missing from an Incomplete t. implements is then
+// false.
+func (t Type) Implements(tpkg Package, i Type, ipkg Package, pointer bool) (implements, known bool) {
+	if i.Kind != "interface" {
+		return false, true
+	}
+	set := t.MethodSet
+	if pointer && t.Kind != "interface" {
+		set = t.PointerMethodSet
+	}
+	methods := make(map[string]string, len(set))
+	for _, m := range set {
+		methods[m.Name] = m.Signature
+	}
+	missing := false
+	for _, m := range i.MethodSet {
+		sig, ok := methods[m.Name]
+		if !ok {
+			missing = true
+			continue
+		}
+		if qualifyType(tpkg, sig) != qualifyType(ipkg, m.Signature) {
+			return false, true
+		}
+	}
+	switch {
+	case missing && !t.Incomplete:
+		return false, true
+	case missing || i.Incomplete:
+		return false, false
+	}
+	return true, true
+}
+
+// namedType describes the declaration of a named type, without method sets.
+func namedType(name string, spec *ast.TypeSpec, pos Position) Type {
+	t := Type{Name: name, Position: pos}
+	switch {
+	case spec.Assign.IsValid():
+		t.Kind = "alias"
+	case isStructType(spec.Type):
+		t.Kind = "struct"
+	case isInterfaceType(spec.Type):
+		t.Kind = "interface"
+	default:
+		t.Kind = "named"
+	}
+	if t.Kind == "alias" || t.Kind == "named" {
+		t.Underlying = justTypeString(getType(spec.Type))
+	}
+	return t
+}
+
+func isStructType(expr ast.Expr) bool {
+	_, ok := expr.(*ast.StructType)
+	return ok
+}
+
+func isInterfaceType(expr ast.Expr) bool {
+	_, ok := expr.(*ast.InterfaceType)
+	return ok
+}
+
+// errorMethod is the method of the predeclared error interface.
+var errorMethod = MethodSetEntry{Name: "Error", Signature: "func() string", Receiver: "error"}
+
+// methodSets computes the method sets of the types of a package from its declarations.
+type methodSets struct {
+	types      map[string]Type
+	structs    map[string]Struct
+	interfaces map[string]Interface
+	declared   map[string][]Method // Methods by receiver base type name
+}
+
+// computeMethodSets fills in the method sets of pkg.Types, given the methods declared in
+// the package by receiver type name.
+func computeMethodSets(pkg *Package, declared map[string][]Method) {
+	m := methodSets{
+		types:      make(map[string]Type, len(pkg.Types)),
+		structs:    make(map[string]Struct, len(pkg.Structs)),
+		interfaces: make(map[string]Interface, len(pkg.Interfaces)),
+		declared:   declared,
+	}
+	for _, t := range pkg.Types {
+		m.types[t.Name] = t
+	}
+	for _, s := range pkg.Structs {
+		m.structs[s.Name] = s
+	}
+	for _, i := range pkg.Interfaces {
+		m.interfaces[i.Name] = i
+	}
+	for k, t := range pkg.Types {
+		if kind, base := m.resolve(t.Name); kind == "interface" {
+			methods, complete := m.interfaceMethods(base, map[string]bool{})
+			pkg.Types[k].MethodSet = methods
+			pkg.Types[k].Incomplete = !complete
+		} else {
+			pkg.Types[k].MethodSet, pkg.Types[k].PointerMethodSet, pkg.Types[k].Incomplete = m.promoted(t.Name)
+		}
+	}
+}
+
+// resolve follows aliases and type definitions naming another type to the declaration
+// providing the underlying type. kind is "struct" or "interface", or "" for other types
+// and for types from other packages, which cannot be looked into; base is then qualified.
+func (m methodSets) resolve(name string) (kind, base string) {
+	seen := map[string]bool{}
+	for !seen[name] {
+		seen[name] = true
+		t, ok := m.types[name]
+		if !ok {
+			if name == "error" {
+				return "interface", name
+			}
+			return "", name
+		}
+		switch t.Kind {
+		case "struct", "interface":
+			return t.Kind, name
+		}
+		if !isTypeName(t.Underlying) {
+			return "", name
+		}
+		name = t.Underlying
+	}
+	return "", name
+}
+
+// isTypeName reports whether typ names a type, like "Base" or "other.Base", rather than
+// being a type literal.
+func isTypeName(typ string) bool {
+	pkg, name, qualified := strings.Cut(typ, ".")
+	if !qualified {
+		return token.IsIdentifier(typ)
+	}
+	return token.IsIdentifier(pkg) && token.IsIdentifier(name)
+}
+
+// canonical returns the type the name denotes, seeing through aliases.
+func (m methodSets) canonical(name string) string {
+	for seen := map[string]bool{}; !seen[name]; {
+		seen[name] = true
+		t, ok := m.types[name]
+		if !ok || t.Kind != "alias" {
+			break
+		}
+		name = t.Underlying
+	}
+	return name
+}
+
+// interfaceMethods returns the method set of the interface name, adding the methods of
+// embedded interfaces. complete is false if an embedded interface is from another package.
+func (m methodSets) interfaceMethods(name string, seen map[string]bool) (methods []MethodSetEntry, complete bool) {
+	if name == "error" {
+		if _, ok := m.types[name]; !ok {
+			return []MethodSetEntry{errorMethod}, true
+		}
+	}
+	complete = true
+	seen[name] = true
+	byName := map[string]bool{}
+	add := func(e MethodSetEntry) {
+		if !byName[e.Name] {
+			byName[e.Name] = true
+			methods = append(methods, e)
+		}
+	}
+	iface := m.interfaces[name]
+	for _, method := range iface.Methods {
+		add(MethodSetEntry{Name: method.Name, Signature: funcTypeString(method.Params, method.Returns), Receiver: name})
+	}
+	for _, embed := range iface.Embeds {
+		kind, base := m.resolve(embed)
+		if kind != "interface" {
+			if strings.Contains(base, ".") {
+				complete = false
+			}
+			continue
+		}
+		if seen[base] {
+			continue
+		}
+		embedded, ok := m.interfaceMethods(base, seen)
+		complete = complete && ok
+		for _, e := range embedded {
+			e.Via = append([]string{embed}, e.Via...)
+			add(e)
+		}
+	}
+	sortMethodSet(methods)
+	return methods, complete
+}
+
+// embedding is a type reached through a path of embedded fields.
+type embedding struct {
+	name     string
+	via      []string
+	indirect bool // An embedded field on the path is a pointer
+}
+
+// candidate is a method found at some depth of embedding.
+type candidate struct {
+	entry     MethodSetEntry
+	ptrRecv   bool
+	addressed bool // The path to the receiver goes through a pointer, so it is addressable
+}
+
+// promoted returns the value and pointer method sets of a non-interface type: its declared
+// methods and those promoted through embedded fields. Embedded types are searched breadth
+// first; a name found at a shallower depth, as a method or field, hides deeper ones, and a
+// name found more than once at the same depth is ambiguous and part of neither set.
+func (m methodSets) promoted(name string) (value, pointer []MethodSetEntry, incomplete bool) {
+	current := []embedding{{name: name}}
+	seen := map[string]bool{}
+	hidden := map[string]bool{}
+	for depth := 0; len(current) > 0; depth++ {
+		found := map[string][]candidate{}
+		fields := map[string]int{}
+		var next []embedding
+		for _, e := range current {
+			typ := m.canonical(e.name)
+			if seen[typ] {
+				continue
+			}
+			// A defined type has its own methods, not those of the type it is defined from
+			kind, base := m.resolve(typ)
+			for _, method := range m.declared[typ] {
+				ptrRecv := strings.HasPrefix(method.Receiver, "*")
+				found[method.Name] = append(found[method.Name], candidate{
+					entry: MethodSetEntry{
+						Name:      method.Name,
+						Signature: funcTypeString(method.Params, method.Returns),
+						Receiver:  method.Receiver,
+						Via:       e.via,
+					},
+					ptrRecv:   ptrRecv,
+					addressed: e.indirect,
+				})
+			}
+
+			switch kind {
+			case "interface":
+				methods, complete := m.interfaceMethods(base, map[string]bool{})
+				incomplete = incomplete || !complete
+				for _, entry := range methods {
+					entry.Via = append(append([]string{}, e.via...), entry.Via...)
+					found[entry.Name] = append(found[entry.Name], candidate{entry: entry})
+				}
+			case "struct":
+				for _, f := range m.structs[base].Fields {
+					if f.Name != "" {
+						fields[f.Name]++
+						continue
+					}
+					fieldName := embeddedTypeName(f)
+					fields[fieldName]++
+					typ := strings.TrimPrefix(f.Type, "*")
+					if strings.Contains(typ, ".") {
+						incomplete = true
+						continue
+					}
+					next = append(next, embedding{
+						name:     typ,
+						via:      append(append([]string{}, e.via...), fieldName),
+						indirect: e.indirect || strings.HasPrefix(f.Type, "*"),
+					})
+				}
+			default:
+				if strings.Contains(base, ".") {
+					incomplete = true
+				}
+			}
+		}
+		for _, e := range current {
+			seen[m.canonical(e.name)] = true
+		}
+
+		for methodName, candidates := range found {
+			if hidden[methodName] || len(candidates)+fields[methodName] != 1 {
+				continue
+			}
+			c := candidates[0]
+			pointer = append(pointer, c.entry)
+			if !c.ptrRecv || c.addressed {
+				value = append(value, c.entry)
+			}
+		}
+		for methodName := range found {
+			hidden[methodName] = true
+		}
+		for fieldName := range fields {
+			hidden[fieldName] = true
+		}
+		current = next
+	}
+	sortMethodSet(value)
+	sortMethodSet(pointer)
+	return value, pointer, incomplete
+}
+
+func sortMethodSet(methods []MethodSetEntry) {
+	sort.Slice(methods, func(i, j int) bool { return methods[i].Name < methods[j].Name })
+}
+
+// typeTable holds the types of every package of an Output for interface satisfaction
+// checks across packages.
+type typeTable map[string]packageType
+
+type packageType struct {
+	pkg Package
+	typ Type
+}
+
+func newTypeTable(out *Output) typeTable {
+	tt := typeTable{}
+	for _, pkg := range out.Packages {
+		for _, t := range pkg.Types {
+			tt[entityPackage(pkg)+"."+t.Name] = packageType{pkg: pkg, typ: t}
+		}
+	}
+	return tt
+}
+
+// implements reports whether s, or a pointer to it, is known to implement i, comparing
+// method sets. Output without method sets, such as decoded from an older version, falls
+// back to comparing declared methods.
+func (tt typeTable) implements(spkg Package, s Struct, ipkg Package, i Interface) bool {
+	st, ok := tt[entityPackage(spkg)+"."+s.Name]
+	it, iok := tt[entityPackage(ipkg)+"."+i.Name]
+	if !ok || !iok {
+		return implementsDeclared(spkg, s, ipkg, i)
+	}
+	implements, known := st.typ.Implements(st.pkg, it.typ, it.pkg, true)
+	return implements && known
+}
+
+// hasMethods reports whether the interface i of pkg has methods, its own or embedded.
+func (tt typeTable) hasMethods(pkg Package, i Interface) bool {
+	if it, ok := tt[entityPackage(pkg)+"."+i.Name]; ok {
+		return len(it.typ.MethodSet) > 0
+	}
+	return len(i.Methods) > 0
+}
@@ -0,0 +1,227 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodSets(t *testing.T) {
+	output, err := ParseSources(map[string]string{"shapes/shapes.go": `package shapes
+
+import "io"
+
+type Namer interface {
+	Name() string
+}
+
+// Shape embeds Namer
+type Shape interface {
+	Namer
+	Area() float64
+}
+
+type ReadNamer interface {
+	io.Reader
+	Namer
+}
+
+type Base struct{ id int }
+
+func (b Base) Name() string  { return "base" }
+func (b *Base) SetName(string) {}
+
+type Inner struct{}
+
+func (Inner) Area() float64 { return 0 }
+func (*Inner) Name() string  { return "inner" }
+
+// Square embeds Base by value and Inner by pointer
+type Square struct {
+	Base
+	*Inner
+	Side float64
+}
+
+func (s Square) Area() float64 { return s.Side * s.Side }
+
+type Left struct{}
+
+func (Left) Close() error { return nil }
+
+type Right struct{}
+
+func (Right) Close() error { return nil }
+
+// Both has an ambiguous Close at depth one
+type Both struct {
+	Left
+	Right
+}
+
+type Shadow struct {
+	Base
+	Name string
+}
+
+type Failure struct{ error }
+
+type Celsius float64
+
+func (c Celsius) String() string { return "" }
+
+type Temp = Celsius
+
+type Remote struct{ io.Writer }
+`})
+	require.NoError(t, err)
+	types := map[string]Type{}
+	for _, typ := range output.Packages[0].Types {
+		types[typ.Name] = typ
+	}
+	names := func(set []MethodSetEntry) []string {
+		out := []string{}
+		for _, m := range set {
+			out = append(out, m.Name)
+		}
+		return out
+	}
+
+	shape := types["Shape"]
+	require.Equal(t, "interface", shape.Kind)
+	require.Equal(t, []string{"Area", "Name"}, names(shape.MethodSet))
+	require.Equal(t, []string{"Namer"}, shape.MethodSet[1].Via)
+	require.Empty(t, shape.PointerMethodSet)
+	require.False(t, shape.Incomplete)
+
+	readNamer := types["ReadNamer"]
+	require.True(t, readNamer.Incomplete)
+	require.Equal(t, []string{"Name"}, names(readNamer.MethodSet))
+	require.Equal(t, []string{"io.Reader", "Namer"}, output.Packages[0].Interfaces[1].Embeds)
+
+	// Square's own Area hides the promoted ones, and Name is found twice at depth one,
+	// through Base and through *Inner, so it is ambiguous
+	square := types["Square"]
+	require.Equal(t, []string{"Area"}, names(square.MethodSet))
+	require.Equal(t, []string{"Area", "SetName"}, names(square.PointerMethodSet))
+	require.Equal(t, MethodSetEntry{Name: "SetName", Signature: "func(string)", Receiver: "*Base", Via: []string{"Base"}}, square.PointerMethodSet[1])
+
+	inner := types["Inner"]
+	require.Equal(t, []string{"Area"}, names(inner.MethodSet))
+	require.Equal(t, []string{"Area", "Name"}, names(inner.PointerMethodSet))
+
+	require.Empty(t, types["Both"].PointerMethodSet)
+	require.Equal(t, []string{"SetName"}, names(types["Shadow"].PointerMethodSet))
+
+	failure := types["Failure"]
+	require.Equal(t, []MethodSetEntry{{Name: "Error", Signature: "func() string", Receiver: "error", Via: []string{"error"}}}, failure.MethodSet)
+
+	celsius := types["Celsius"]
+	require.Equal(t, "named", celsius.Kind)
+	require.Equal(t, "float64", celsius.Underlying)
+	require.Equal(t, []string{"String"}, names(celsius.MethodSet))
+	temp := types["Temp"]
+	require.Equal(t, "alias", temp.Kind)
+	require.Equal(t, []string{"String"}, names(temp.MethodSet))
+
+	require.True(t, types["Remote"].Incomplete)
+
+	pkg := output.Packages[0]
+	implements := func(typ, iface string, pointer bool) bool {
+		ok, known := types[typ].Implements(pkg, types[iface], pkg, pointer)
+		require.True(t, known, "%s %s", typ, iface)
+		return ok
+	}
+	require.True(t, implements("Inner", "Namer", true))
+	require.False(t, implements("Inner", "Namer", false))
+	require.True(t, implements("Base", "Namer", false))
+	require.False(t, implements("Square", "Shape", true))
+	require.False(t, implements("Square", "Base", true))
+
+	// Whether Base implements ReadNamer depends on io.Reader
+	ok, known := types["Base"].Implements(pkg, readNamer, pkg, true)
+	require.False(t, ok)
+	require.False(t, known)
+}
+
+func TestMethodSetsEmbeddedPointer(t *testing.T) {
+	output, err := ParseSources(map[string]string{"store/store.go": `package store
+
+type Saver interface {
+	Save() error
+}
+
+type Record struct{}
+
+func (r *Record) Save() error { return nil }
+
+// ByValue needs to be addressable to call Save
+type ByValue struct{ Record }
+
+// ByPointer can always call Save
+type ByPointer struct{ *Record }
+
+type Nested struct{ ByPointer }
+`})
+	require.NoError(t, err)
+	types := map[string]Type{}
+	for _, typ := range output.Packages[0].Types {
+		types[typ.Name] = typ
+	}
+	pkg := output.Packages[0]
+	implements := func(typ string, pointer bool) bool {
+		ok, known := types[typ].Implements(pkg, types["Saver"], pkg, pointer)
+		require.True(t, known, typ)
+		return ok
+	}
+
+	require.False(t, implements("ByValue", false))
+	require.True(t, implements("ByValue", true))
+	require.True(t, implements("ByPointer", false))
+	require.True(t, implements("Nested", false))
+	require.Equal(t, []string{"ByPointer", "Record"}, types["Nested"].MethodSet[0].Via)
+
+	// Structs with promoted methods show up as implementors
+	implementors, ok := findImplementors(output, "Saver")
+	require.True(t, ok)
+	var names []string
+	for _, e := range implementors {
+		names = append(names, e.Name)
+	}
+	require.ElementsMatch(t, []string{"Record", "ByValue", "ByPointer", "Nested"}, names)
+}
+
+func TestImplementsQualifiesSignatures(t *testing.T) {
+	output, err := ParseSources(map[string]string{
+		"a/a.go": `package a
+
+type User struct{}
+
+type Saver interface {
+	Save(User) error
+}
+`,
+		"b/b.go": `package b
+
+type User struct{}
+
+type Store struct{}
+
+func (s *Store) Save(u User) error { return nil }
+
+type Saver interface {
+	Save(User) error
+}
+`,
+	})
+	require.NoError(t, err)
+
+	// b.Store saves a b.User, not the a.User of a.Saver
+	implementors, ok := findImplementors(output, "a.Saver")
+	require.True(t, ok)
+	require.Empty(t, implementors)
+	implementors, ok = findImplementors(output, "b.Saver")
+	require.True(t, ok)
+	require.Len(t, implementors, 1)
+	require.Equal(t, "Store", implementors[0].Name)
+}
@@ -0,0 +1,77 @@
+package structparser
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// msgpackEncoding writes Output as MessagePack maps keyed by the JSON field names.
+type msgpackEncoding struct{}
+
+func (msgpackEncoding) Encode(w io.Writer, out *Output) error {
+	tree, err := orderedTree(out)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	if err := encodeMsgpack(msgpack.NewEncoder(bw), tree); err != nil {
+		return fmt.Errorf("msgpack: %w", err)
+	}
+	return bw.Flush()
+}
+
+func (msgpackEncoding) Decode(r io.Reader) (*Output, error) {
+	tree, err := msgpack.NewDecoder(bufio.NewReader(r)).DecodeInterface()
+	if err != nil {
+		return nil, fmt.Errorf("msgpack: %w", err)
+	}
+	return outputFromTree(tree)
+}
+
+// encodeMsgpack writes a value of orderedTree, keeping the key order of objects and
+// writing numbers as integers when they have no fractional part.
+func encodeMsgpack(enc *msgpack.Encoder, v interface{}) error {
+	switch v := v.(type) {
+	case orderedMap:
+		if err := enc.EncodeMapLen(len(v)); err != nil {
+			return err
+		}
+		for _, e := range v {
+			if err := enc.EncodeString(e.Key); err != nil {
+				return err
+			}
+			if err := encodeMsgpack(enc, e.Value); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		if err := enc.EncodeArrayLen(len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if err := encodeMsgpack(enc, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return enc.EncodeInt(n)
+		}
+		if n, err := strconv.ParseUint(v.String(), 10, 64); err == nil {
+			return enc.EncodeUint(n)
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		return enc.EncodeFloat64(f)
+	}
+	return enc.Encode(v)
+}
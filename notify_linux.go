@@ -0,0 +1,145 @@
+//go:build linux
+
+package structparser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_CREATE | syscall.IN_MODIFY |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+// inotifyNotifier watches directories with inotify(7).
+type inotifyNotifier struct {
+	fd   int
+	file *os.File
+	dirs map[int32]inotifyDir // Watch descriptor to directory
+}
+
+type inotifyDir struct {
+	path      string
+	recursive bool // Under the root of a "dir/..." pattern, so new subdirectories are watched
+}
+
+func newNotifier(dirs, roots []string) (notifier, error) {
+	// A non-blocking descriptor goes through the runtime poller, so Close interrupts Read
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	n := &inotifyNotifier{fd: fd, dirs: map[int32]inotifyDir{}}
+	for _, dir := range dirs {
+		if err := n.add(dir, false); err != nil {
+			syscall.Close(fd)
+			return nil, err
+		}
+	}
+	for _, root := range roots {
+		if _, err := n.addTree(root); err != nil {
+			syscall.Close(fd)
+			return nil, err
+		}
+	}
+	n.file = os.NewFile(uintptr(fd), "inotify")
+	return n, nil
+}
+
+func (n *inotifyNotifier) add(dir string, recursive bool) error {
+	wd, err := syscall.InotifyAddWatch(n.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	// A directory that is both loaded and under a root is watched once, recursively
+	recursive = recursive || n.dirs[int32(wd)].recursive
+	n.dirs[int32(wd)] = inotifyDir{path: dir, recursive: recursive}
+	return nil
+}
+
+// addTree watches root and the directories below it, and returns the .go files they
+// already hold.
+func (n *inotifyNotifier) addTree(root string) ([]string, error) {
+	var (
+		files []string
+		first error
+	)
+	walkTree(root, func(dir string) {
+		if err := n.add(dir, true); err != nil {
+			if first == nil {
+				first = err
+			}
+			return
+		}
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".go") {
+				files = append(files, filepath.Join(dir, entry.Name()))
+			}
+		}
+	})
+	return files, first
+}
+
+func (n *inotifyNotifier) run(ctx context.Context, out chan<- string) error {
+	go func() {
+		<-ctx.Done()
+		n.file.Close()
+	}()
+
+	send := func(path string) error {
+		select {
+		case out <- path:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return err
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + syscall.SizeofInotifyEvent
+			offset = start + int(event.Len)
+			name := strings.TrimRight(string(buf[start:offset]), "\x00")
+			dir, ok := n.dirs[event.Wd]
+			switch {
+			case !ok:
+				continue
+			case event.Mask&syscall.IN_IGNORED != 0:
+				// The directory was removed or unmounted
+				delete(n.dirs, event.Wd)
+				continue
+			case event.Mask&syscall.IN_ISDIR != 0:
+				if !dir.recursive || event.Mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) == 0 || ignoredDir(name) {
+					continue
+				}
+				// Files may have been written before the watch was added, so report
+				// those already there
+				files, _ := n.addTree(filepath.Join(dir.path, name))
+				for _, path := range files {
+					if err := send(path); err != nil {
+						return err
+					}
+				}
+				continue
+			case !strings.HasSuffix(name, ".go"):
+				continue
+			}
+			if err := send(filepath.Join(dir.path, name)); err != nil {
+				return err
+			}
+		}
+	}
+}
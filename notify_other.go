@@ -0,0 +1,9 @@
+//go:build !linux
+
+package structparser
+
+import "errors"
+
+func newNotifier(dirs, roots []string) (notifier, error) {
+	return nil, errors.New("file system notifications are not supported on this platform")
+}
@@ -0,0 +1,359 @@
+package structparser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenAPIFormat selects the encoding produced by GenerateOpenAPI.
+type OpenAPIFormat string
+
+const (
+	OpenAPIJSON OpenAPIFormat = "json"
+	OpenAPIYAML OpenAPIFormat = "yaml"
+)
+
+// OpenAPIOptions configures GenerateOpenAPI.
+type OpenAPIOptions struct {
+	// Format defaults to OpenAPIJSON.
+	Format OpenAPIFormat
+}
+
+// openAPISchema is the subset of the OpenAPI 3.1 (JSON Schema 2020-12) schema object
+// the generator emits.
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 interface{}               `json:"type,omitempty" yaml:"type,omitempty"` // string or []string when nullable
+	Format               string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string                    `json:"description,omitempty" yaml:"description,omitempty"`
+	AllOf                []*openAPISchema          `json:"allOf,omitempty" yaml:"allOf,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Enum                 []interface{}             `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Minimum              *float64                  `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Maximum              *float64                  `json:"maximum,omitempty" yaml:"maximum,omitempty"`
+	MinLength            *int                      `json:"minLength,omitempty" yaml:"minLength,omitempty"`
+	MaxLength            *int                      `json:"maxLength,omitempty" yaml:"maxLength,omitempty"`
+	MinItems             *int                      `json:"minItems,omitempty" yaml:"minItems,omitempty"`
+	MaxItems             *int                      `json:"maxItems,omitempty" yaml:"maxItems,omitempty"`
+	Examples             []interface{}             `json:"examples,omitempty" yaml:"examples,omitempty"`
+}
+
+type openAPIDocument struct {
+	Components struct {
+		Schemas map[string]*openAPISchema `json:"schemas" yaml:"schemas"`
+	} `json:"components" yaml:"components"`
+}
+
+// GenerateOpenAPI produces an OpenAPI 3.1 document containing only components.schemas,
+// one schema per exported struct and enum, ready to be merged with hand-written paths.
+//
+// Property names follow `json` tags, `validate` tags (required, min, max, len, gte, lte,
+// oneof, email, url, uuid) become constraints, `example` tags become examples and docs
+// become descriptions. Schemas are named after their Go types, and references to types of
+// other packages resolve by type name, so two packages declaring the same exported name
+// are an error.
+func GenerateOpenAPI(out *Output, opts OpenAPIOptions) ([]byte, error) {
+	g := &openAPIGenerator{known: map[string]bool{}}
+	for _, pkg := range out.Packages {
+		for _, s := range pkg.Structs {
+			g.known[s.Name] = true
+		}
+		for _, e := range pkg.Enums {
+			g.known[e.Name] = true
+		}
+	}
+
+	doc := openAPIDocument{}
+	doc.Components.Schemas = map[string]*openAPISchema{}
+	declaredBy := map[string]string{}
+	add := func(pkg Package, name string, schema *openAPISchema) error {
+		if other, ok := declaredBy[name]; ok {
+			return fmt.Errorf("schema %s is declared by both %s and %s", name, other, entityPackage(pkg))
+		}
+		declaredBy[name] = entityPackage(pkg)
+		doc.Components.Schemas[name] = schema
+		return nil
+	}
+	for _, pkg := range out.Packages {
+		for _, e := range pkg.Enums {
+			if !ast.IsExported(e.Name) {
+				continue
+			}
+			if err := add(pkg, e.Name, g.enumSchema(e)); err != nil {
+				return nil, err
+			}
+		}
+		for _, s := range pkg.Structs {
+			if !ast.IsExported(s.Name) {
+				continue
+			}
+			schema, err := g.structSchema(s)
+			if err != nil {
+				return nil, err
+			}
+			if err := add(pkg, s.Name, schema); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	switch opts.Format {
+	case OpenAPIYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	case OpenAPIJSON, "":
+		return json.MarshalIndent(doc, "", "  ")
+	}
+	return nil, fmt.Errorf("unknown OpenAPI format %q", opts.Format)
+}
+
+type openAPIGenerator struct {
+	known map[string]bool // Types that get their own component schema
+}
+
+func (g *openAPIGenerator) enumSchema(e Enum) *openAPISchema {
+	schema := &openAPISchema{
+		Type:        "string",
+		Description: strings.Join(e.Docs, "\n"),
+	}
+	if e.Type != "string" {
+		schema.Type = "integer"
+	}
+	for _, v := range e.Values {
+		schema.Enum = append(schema.Enum, openAPILiteral(v.Value))
+	}
+	return schema
+}
+
+func (g *openAPIGenerator) structSchema(s Struct) (*openAPISchema, error) {
+	object := &openAPISchema{
+		Type:       "object",
+		Properties: map[string]*openAPISchema{},
+	}
+	embedded := []*openAPISchema{}
+	for _, f := range s.Fields {
+		if f.Name == "" {
+			if name, _, _ := lookupTag(f.Tag, "json"); name == "" {
+				// Embedded structs are flattened by encoding/json
+				embedded = append(embedded, g.typeSchema(parseTypeString(strings.TrimPrefix(f.Type, "*"))))
+				continue
+			}
+			f.Name = embeddedTypeName(f)
+		}
+		name, _, ok := jsonName(f)
+		if !ok {
+			continue
+		}
+
+		expr := parseTypeString(f.Type)
+		property := g.typeSchema(expr)
+		if _, isPointer := expr.(*ast.StarExpr); isPointer {
+			if typ, ok := property.Type.(string); ok {
+				property.Type = []string{typ, "null"}
+			}
+		}
+
+		docs := append([]string{}, f.Docs...)
+		if f.Comment != "" {
+			docs = append(docs, f.Comment)
+		}
+		// OpenAPI 3.1 allows a description next to $ref
+		property.Description = strings.Join(docs, "\n")
+
+		required, err := applyValidateTag(property, f.Tag)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", s.Name, f.Name, err)
+		}
+		if required {
+			object.Required = append(object.Required, name)
+		}
+		if example, ok := lookupExample(f.Tag); ok {
+			property.Examples = []interface{}{typedLiteral(property, example)}
+		}
+		object.Properties[name] = property
+	}
+
+	description := strings.Join(s.Docs, "\n")
+	if len(embedded) == 0 {
+		object.Description = description
+		return object, nil
+	}
+	return &openAPISchema{
+		Description: description,
+		AllOf:       append(embedded, object),
+	}, nil
+}
+
+// typeSchema maps a Go type expression to the schema of its JSON encoding.
+func (g *openAPIGenerator) typeSchema(expr ast.Expr) *openAPISchema {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "string":
+			return &openAPISchema{Type: "string"}
+		case "bool":
+			return &openAPISchema{Type: "boolean"}
+		case "int", "int64", "uint", "uint64", "uintptr":
+			return &openAPISchema{Type: "integer", Format: "int64"}
+		case "int8", "int16", "int32", "uint8", "uint16", "uint32", "byte", "rune":
+			return &openAPISchema{Type: "integer", Format: "int32"}
+		case "float32":
+			return &openAPISchema{Type: "number", Format: "float"}
+		case "float64":
+			return &openAPISchema{Type: "number", Format: "double"}
+		}
+		if g.known[e.Name] {
+			return &openAPISchema{Ref: "#/components/schemas/" + e.Name}
+		}
+	case *ast.SelectorExpr:
+		switch justTypeString(getType(e)) {
+		case "time.Time":
+			return &openAPISchema{Type: "string", Format: "date-time"}
+		case "time.Duration":
+			return &openAPISchema{Type: "integer", Format: "int64"}
+		case "uuid.UUID":
+			return &openAPISchema{Type: "string", Format: "uuid"}
+		}
+		if g.known[e.Sel.Name] {
+			return &openAPISchema{Ref: "#/components/schemas/" + e.Sel.Name}
+		}
+	case *ast.StarExpr:
+		return g.typeSchema(e.X)
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") && e.Len == nil {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: g.typeSchema(e.Elt)}
+	case *ast.MapType:
+		return &openAPISchema{Type: "object", AdditionalProperties: g.typeSchema(e.Value)}
+	}
+	// Anything else (interfaces, funcs, unparsed packages) accepts any value
+	return &openAPISchema{}
+}
+
+// applyValidateTag translates go-playground/validator rules into schema constraints and
+// reports whether the field is required.
+func applyValidateTag(schema *openAPISchema, tag string) (required bool, err error) {
+	name, options, ok := lookupTag(tag, "validate")
+	if !ok {
+		return false, nil
+	}
+	kind := schemaKind(schema)
+	for _, rule := range append([]string{name}, options...) {
+		key, value, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "url", "uri":
+			schema.Format = "uri"
+		case "uuid", "uuid4":
+			schema.Format = "uuid"
+		case "oneof":
+			for _, v := range strings.Fields(value) {
+				schema.Enum = append(schema.Enum, typedLiteral(schema, v))
+			}
+		case "min", "max", "len", "gte", "lte":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, fmt.Errorf("invalid validate rule %q", rule)
+			}
+			lower := key == "min" || key == "gte" || key == "len"
+			upper := key == "max" || key == "lte" || key == "len"
+			switch kind {
+			case "string":
+				if lower {
+					schema.MinLength = intPtr(int(n))
+				}
+				if upper {
+					schema.MaxLength = intPtr(int(n))
+				}
+			case "array":
+				if lower {
+					schema.MinItems = intPtr(int(n))
+				}
+				if upper {
+					schema.MaxItems = intPtr(int(n))
+				}
+			case "integer", "number":
+				if lower {
+					schema.Minimum = &n
+				}
+				if upper {
+					schema.Maximum = &n
+				}
+			}
+		}
+	}
+	return required, nil
+}
+
+// lookupExample returns the raw value of the `example` tag.
+func lookupExample(tag string) (string, bool) {
+	name, options, ok := lookupTag(tag, "example")
+	if !ok {
+		return "", false
+	}
+	// Examples may legitimately contain commas
+	return strings.Join(append([]string{name}, options...), ","), true
+}
+
+// schemaKind returns the non-null JSON type of a schema.
+func schemaKind(schema *openAPISchema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []string:
+		return t[0]
+	}
+	return ""
+}
+
+// typedLiteral converts a tag value to the JSON type of the schema it applies to.
+func typedLiteral(schema *openAPISchema, value string) interface{} {
+	switch schemaKind(schema) {
+	case "integer":
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return n
+		}
+	case "number":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(value); err == nil {
+			return b
+		}
+	}
+	return value
+}
+
+// openAPILiteral converts an evaluated Go literal (as stored in EnumValue) to JSON.
+func openAPILiteral(value string) interface{} {
+	if s, err := strconv.Unquote(value); err == nil {
+		return s
+	}
+	if n, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return n
+	}
+	return value
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,90 @@
+package structparser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	code := `
+	package api
+
+	type Role string
+
+	const (
+		RoleAdmin Role = "admin"
+		RoleUser  Role = "user"
+	)
+
+	type Base struct {
+		ID int64 ` + "`json:\"id\" example:\"42\"`" + `
+	}
+
+	// Account is a user account
+	type Account struct {
+		Base
+		// Email address used to log in
+		Email    string   ` + "`json:\"email\" validate:\"required,email\"`" + `
+		Name     *string  ` + "`json:\"name\" validate:\"min=2,max=64\"`" + `
+		Age      int      ` + "`json:\"age\" validate:\"gte=18\"`" + `
+		Plan     string   ` + "`json:\"plan\" validate:\"oneof=free pro\" example:\"pro\"`" + `
+		Role     Role     ` + "`json:\"role\"`" + `
+		Tags     []string ` + "`json:\"tags,omitempty\" validate:\"max=5\"`" + `
+		password string
+	}
+	`
+	output, err := ParseString(code)
+	require.NoError(t, err)
+
+	raw, err := GenerateOpenAPI(output, OpenAPIOptions{})
+	require.NoError(t, err)
+
+	var doc map[string]map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &doc))
+	schemas := doc["components"]["schemas"]
+	require.Len(t, schemas, 3)
+
+	require.Equal(t, map[string]interface{}{"type": "string", "enum": []interface{}{"admin", "user"}}, schemas["Role"])
+
+	account := schemas["Account"].(map[string]interface{})
+	require.Equal(t, "Account is a user account", account["description"])
+	allOf := account["allOf"].([]interface{})
+	require.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/Base"}, allOf[0])
+
+	object := allOf[1].(map[string]interface{})
+	require.Equal(t, []interface{}{"email"}, object["required"])
+	props := object["properties"].(map[string]interface{})
+	require.Len(t, props, 6)
+	require.Equal(t, map[string]interface{}{"type": "string", "format": "email", "description": "Email address used to log in"}, props["email"])
+	require.Equal(t, map[string]interface{}{"type": []interface{}{"string", "null"}, "minLength": 2.0, "maxLength": 64.0}, props["name"])
+	require.Equal(t, map[string]interface{}{"type": "integer", "format": "int64", "minimum": 18.0}, props["age"])
+	require.Equal(t, map[string]interface{}{"type": "string", "enum": []interface{}{"free", "pro"}, "examples": []interface{}{"pro"}}, props["plan"])
+	require.Equal(t, map[string]interface{}{"$ref": "#/components/schemas/Role"}, props["role"])
+	require.Equal(t, map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "maxItems": 5.0}, props["tags"])
+
+	base := schemas["Base"].(map[string]interface{})
+	require.Equal(t, []interface{}{42.0}, base["properties"].(map[string]interface{})["id"].(map[string]interface{})["examples"])
+
+	t.Run("YAML", func(t *testing.T) {
+		raw, err := GenerateOpenAPI(output, OpenAPIOptions{Format: OpenAPIYAML})
+		require.NoError(t, err)
+
+		var fromYAML map[string]interface{}
+		require.NoError(t, yaml.Unmarshal(raw, &fromYAML))
+		components := fromYAML["components"].(map[string]interface{})
+		require.Contains(t, components["schemas"], "Account")
+		require.Contains(t, string(raw), "$ref: '#/components/schemas/Role'")
+	})
+}
+
+func TestGenerateOpenAPINameCollision(t *testing.T) {
+	out := &Output{Packages: []Package{
+		{Package: "a", Path: "example.com/a", Structs: []Struct{{Name: "User"}}},
+		{Package: "b", Path: "example.com/b", Structs: []Struct{{Name: "User"}}},
+	}}
+	_, err := GenerateOpenAPI(out, OpenAPIOptions{})
+	require.EqualError(t, err, "schema User is declared by both example.com/a and example.com/b")
+}
@@ -0,0 +1,320 @@
+package structparser
+
+import (
+	"bytes"
+	"context"
+	"go/build"
+	"go/build/constraint"
+	"go/token"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ParseOptions configures which files make up a package. The zero value includes every
+// non-test .go file whatever its build constraints; set BuildConstraints to select files
+// for a target platform like `go build`.
+type ParseOptions struct {
+	BuildConstraints bool     // Skip files whose build constraints GOOS, GOARCH and BuildTags do not satisfy
+	GOOS             string   // Target operating system, defaults to $GOOS or else runtime.GOOS, as in go/build
+	GOARCH           string   // Target architecture, defaults to $GOARCH or else runtime.GOARCH, as in go/build
+	BuildTags        []string // Extra build tags that are satisfied (e.g., "integration")
+	Tests            bool     // Include _test.go files and catalog their tests in Package.Tests
+	Workers          int      // Directories parsed concurrently by ParseDirectories, defaults to GOMAXPROCS
+	Cache            *Cache   // When set, declarations of files that did not change are read from the cache
+	Layout           bool     // Compute the memory layout of structs for GOARCH, see ComputeLayout
+
+	// Filter, when set, is called for every .go file of a directory; files it rejects are
+	// skipped before build constraints are evaluated.
+	Filter func(fs.FileInfo) bool
+}
+
+// ParseDirectory parses a package directory, or a single Go file. A file named
+// explicitly is parsed whatever its build constraints and even if it is a test file, as
+// the go command does.
+func (o ParseOptions) ParseDirectory(fileOrDirectory string) (*Output, error) {
+	output, err := o.parseDirectory(context.Background(), token.NewFileSet(), fileOrDirectory)
+	if err != nil {
+		return nil, err
+	}
+	return output, o.layout(output)
+}
+
+func (o ParseOptions) parseDirectory(ctx context.Context, fset *token.FileSet, fileOrDirectory string) (*Output, error) {
+	fi, err := os.Stat(fileOrDirectory)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := fileOrDirectory
+	filter := o.Filter
+	rules := o.fileRules()
+	if fi.Mode().IsRegular() {
+		dir = filepath.Dir(fileOrDirectory)
+		name := filepath.Base(fileOrDirectory)
+		filter = func(fi fs.FileInfo) bool { return fi.Name() == name }
+		rules.useAllFiles = true
+	}
+
+	files, err := readFSFiles(os.DirFS(dir), ".", filter, dir)
+	if err != nil {
+		return nil, err
+	}
+	output, err := o.parseFiles(ctx, fset, files, rules)
+	if err != nil {
+		return nil, err
+	}
+	importPath := importPathForDir(dir)
+	for i := range output.Packages {
+		output.Packages[i].Path = importPath
+	}
+	return output, nil
+}
+
+// ParseFS is the ParseOptions counterpart of the package level ParseFS.
+func (o ParseOptions) ParseFS(fsys fs.FS, dir string) (*Output, error) {
+	files, err := readFSFiles(fsys, dir, o.Filter, "")
+	if err != nil {
+		return nil, err
+	}
+	output, err := o.parseFiles(context.Background(), token.NewFileSet(), files, o.fileRules())
+	if err != nil {
+		return nil, err
+	}
+	importPath := importPathForFS(fsys, dir)
+	for i := range output.Packages {
+		output.Packages[i].Path = importPath
+	}
+	return output, o.layout(output)
+}
+
+// ParseSources is the ParseOptions counterpart of the package level ParseSources.
+func (o ParseOptions) ParseSources(sources map[string]string) (*Output, error) {
+	output, err := o.parseFiles(context.Background(), token.NewFileSet(), sortedSources(sources), o.fileRules())
+	if err != nil {
+		return nil, err
+	}
+	return output, o.layout(output)
+}
+
+// layout applies ComputeLayout when o.Layout is set. It runs on complete outputs, after
+// the cache, so that field types from every parsed package can be sized.
+func (o ParseOptions) layout(output *Output) error {
+	if !o.Layout {
+		return nil
+	}
+	return ComputeLayout(output, o.fileRules().ctxt.GOARCH)
+}
+
+// parseFiles is parseSourceFiles with o.Cache.
+func (o ParseOptions) parseFiles(ctx context.Context, fset *token.FileSet, files []sourceFile, rules fileRules) (*Output, error) {
+	return parseSourceFiles(ctx, fset, files, rules, o.Cache)
+}
+
+func (o ParseOptions) fileRules() fileRules {
+	ctxt := build.Default
+	if o.GOOS != "" {
+		ctxt.GOOS = o.GOOS
+	}
+	if o.GOARCH != "" {
+		ctxt.GOARCH = o.GOARCH
+	}
+	ctxt.BuildTags = o.BuildTags
+	// Cgo files are Go files as far as declarations are concerned
+	ctxt.CgoEnabled = true
+	return fileRules{ctxt: ctxt, includeTests: o.Tests, constraints: o.BuildConstraints}
+}
+
+// fileRules decide which files make up a package. Every loader applies them so that a
+// directory, a file system and in-memory sources give the same result.
+type fileRules struct {
+	ctxt         build.Context
+	includeTests bool
+	constraints  bool // Evaluate build constraints against ctxt, otherwise every file matches
+
+	// useAllFiles skips build constraints, as the go command does for files named on the
+	// command line.
+	useAllFiles bool
+}
+
+// match reports whether the file is a Go file satisfied by the build constraints of the
+// target platform (file name suffixes such as _windows.go and //go:build lines), when
+// constraints is set. Test files are kept only when includeTests is set. Like the go
+// command, files starting with "_" or "." are then ignored.
+func (r fileRules) match(name string, content []byte) (bool, error) {
+	base := filepath.Base(name)
+	if !strings.HasSuffix(base, ".go") {
+		return false, nil
+	}
+	if r.useAllFiles {
+		return true, nil
+	}
+	if !r.includeTests && strings.HasSuffix(base, "_test.go") {
+		return false, nil
+	}
+	if !r.constraints {
+		return true, nil
+	}
+	ctxt := r.ctxt
+	ctxt.OpenFile = func(string) (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(content)), nil
+	}
+	return ctxt.MatchFile(filepath.Dir(name), base)
+}
+
+// Known values of GOOS and GOARCH, as recognized in file name suffixes by go/build.
+var (
+	knownOS = map[string]bool{
+		"aix": true, "android": true, "darwin": true, "dragonfly": true, "freebsd": true,
+		"hurd": true, "illumos": true, "ios": true, "js": true, "linux": true, "nacl": true,
+		"netbsd": true, "openbsd": true, "plan9": true, "solaris": true, "wasip1": true,
+		"windows": true, "zos": true,
+	}
+	knownArch = map[string]bool{
+		"386": true, "amd64": true, "amd64p32": true, "arm": true, "armbe": true, "arm64": true,
+		"arm64be": true, "loong64": true, "mips": true, "mipsle": true, "mips64": true,
+		"mips64le": true, "mips64p32": true, "mips64p32le": true, "ppc": true, "ppc64": true,
+		"ppc64le": true, "riscv": true, "riscv64": true, "s390": true, "s390x": true,
+		"sparc": true, "sparc64": true, "wasm": true,
+	}
+)
+
+// fileConstraint returns the build constraint of a file as a //go:build expression,
+// combining the file's //go:build (or // +build) lines with the GOOS and GOARCH implied by
+// its name. It returns "" for unconstrained files.
+func fileConstraint(name string, content []byte) string {
+	var exprs []constraint.Expr
+	if expr := headerConstraint(content); expr != nil {
+		exprs = append(exprs, expr)
+	}
+	if expr := nameConstraint(filepath.Base(name)); expr != nil {
+		exprs = append(exprs, expr)
+	}
+	if len(exprs) == 0 {
+		return ""
+	}
+	expr := exprs[0]
+	for _, x := range exprs[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: x}
+	}
+	return expr.String()
+}
+
+// headerConstraint parses the build lines of the comments before the package clause of a
+// file. Line and block comments may be mixed; only line comments hold build lines. A
+// //go:build line takes precedence over // +build lines.
+func headerConstraint(content []byte) constraint.Expr {
+	var (
+		goBuild constraint.Expr
+		plus    []constraint.Expr
+	)
+	rest := content
+	for {
+		rest = bytes.TrimLeft(rest, " \t\r\n")
+		if bytes.HasPrefix(rest, []byte("/*")) {
+			end := bytes.Index(rest[2:], []byte("*/"))
+			if end < 0 {
+				break
+			}
+			rest = rest[2+end+2:]
+			continue
+		}
+		if !bytes.HasPrefix(rest, []byte("//")) {
+			break
+		}
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, rest = rest[:i], rest[i+1:]
+		} else {
+			rest = nil
+		}
+		text := strings.TrimSpace(string(line))
+		switch {
+		case constraint.IsGoBuild(text):
+			if expr, err := constraint.Parse(text); err == nil && goBuild == nil {
+				goBuild = expr
+			}
+		case constraint.IsPlusBuild(text):
+			if expr, err := constraint.Parse(text); err == nil {
+				plus = append(plus, expr)
+			}
+		}
+	}
+	if goBuild != nil || len(plus) == 0 {
+		return goBuild
+	}
+	expr := plus[0]
+	for _, x := range plus[1:] {
+		expr = &constraint.AndExpr{X: expr, Y: x}
+	}
+	return expr
+}
+
+// nameConstraint returns the constraint implied by _GOOS, _GOARCH and _GOOS_GOARCH file
+// name suffixes, optionally followed by _test.
+func nameConstraint(base string) constraint.Expr {
+	name := base
+	if dot := strings.Index(name, "."); dot >= 0 {
+		name = name[:dot]
+	}
+	// The part before the first underscore never counts, so linux.go is unconstrained
+	i := strings.Index(name, "_")
+	if i < 0 {
+		return nil
+	}
+	parts := strings.Split(name[i:], "_")
+	if n := len(parts); n > 0 && parts[n-1] == "test" {
+		parts = parts[:n-1]
+	}
+	n := len(parts)
+	switch {
+	case n >= 2 && knownOS[parts[n-2]] && knownArch[parts[n-1]]:
+		return &constraint.AndExpr{X: &constraint.TagExpr{Tag: parts[n-2]}, Y: &constraint.TagExpr{Tag: parts[n-1]}}
+	case n >= 1 && (knownOS[parts[n-1]] || knownArch[parts[n-1]]):
+		return &constraint.TagExpr{Tag: parts[n-1]}
+	}
+	return nil
+}
+
+// forEachPosition calls fn with the position and build constraint of every entity of out.
+func forEachPosition(out *Output, fn func(pos *Position, constraint *string)) {
+	for p := range out.Packages {
+		pkg := &out.Packages[p]
+		for i := range pkg.Structs {
+			s := &pkg.Structs[i]
+			fn(&s.Position, &s.Constraint)
+			for j := range s.Fields {
+				fn(&s.Fields[j].Position, &s.Fields[j].Constraint)
+			}
+			for j := range s.Methods {
+				fn(&s.Methods[j].Position, &s.Methods[j].Constraint)
+			}
+		}
+		for i := range pkg.Interfaces {
+			fn(&pkg.Interfaces[i].Position, &pkg.Interfaces[i].Constraint)
+			for j := range pkg.Interfaces[i].Methods {
+				fn(&pkg.Interfaces[i].Methods[j].Position, &pkg.Interfaces[i].Methods[j].Constraint)
+			}
+		}
+		for i := range pkg.Functions {
+			fn(&pkg.Functions[i].Position, &pkg.Functions[i].Constraint)
+		}
+		for i := range pkg.Variables {
+			fn(&pkg.Variables[i].Position, &pkg.Variables[i].Constraint)
+		}
+		for i := range pkg.Constants {
+			fn(&pkg.Constants[i].Position, &pkg.Constants[i].Constraint)
+		}
+		for i := range pkg.Enums {
+			fn(&pkg.Enums[i].Position, &pkg.Enums[i].Constraint)
+			for j := range pkg.Enums[i].Values {
+				fn(&pkg.Enums[i].Values[j].Position, &pkg.Enums[i].Values[j].Constraint)
+			}
+		}
+		for i := range pkg.Tests {
+			fn(&pkg.Tests[i].Position, &pkg.Tests[i].Constraint)
+		}
+	}
+}
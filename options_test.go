@@ -0,0 +1,82 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var platformSources = map[string]string{
+	"conn.go":             "package conn\n\ntype Conn struct{}\n",
+	"conn_linux.go":       "package conn\n\ntype Handle struct{ FD int }\n",
+	"conn_windows.go":     "package conn\n\ntype Handle struct{ Handle uintptr }\n",
+	"conn_linux_arm64.go": "package conn\n\ntype ARM struct{}\n",
+	"integration.go":      "//go:build integration && !race\n\npackage conn\n\ntype Fixture struct{}\n",
+	"legacy.go":           "// +build linux,386\n\npackage conn\n\ntype Legacy struct{}\n",
+}
+
+func structsByName(t *testing.T, output *Output) map[string]Struct {
+	t.Helper()
+	require.Len(t, output.Packages, 1)
+	structs := map[string]Struct{}
+	for _, s := range output.Packages[0].Structs {
+		structs[s.Name] = s
+	}
+	return structs
+}
+
+func TestParseOptions(t *testing.T) {
+	t.Run("Linux", func(t *testing.T) {
+		output, err := ParseOptions{BuildConstraints: true, GOOS: "linux", GOARCH: "amd64"}.ParseSources(platformSources)
+		require.NoError(t, err)
+		structs := structsByName(t, output)
+		require.Len(t, structs, 2)
+		require.Equal(t, "", structs["Conn"].Constraint)
+		require.Equal(t, "linux", structs["Handle"].Constraint)
+		require.Equal(t, "linux", structs["Handle"].Fields[0].Constraint)
+		require.Equal(t, "FD", structs["Handle"].Fields[0].Name)
+	})
+
+	t.Run("WindowsWithTags", func(t *testing.T) {
+		output, err := ParseOptions{BuildConstraints: true, GOOS: "windows", GOARCH: "amd64", BuildTags: []string{"integration"}}.ParseSources(platformSources)
+		require.NoError(t, err)
+		structs := structsByName(t, output)
+		require.Len(t, structs, 3)
+		require.Equal(t, "windows", structs["Handle"].Constraint)
+		require.Equal(t, "integration && !race", structs["Fixture"].Constraint)
+	})
+
+	t.Run("LinuxARM64", func(t *testing.T) {
+		output, err := ParseOptions{BuildConstraints: true, GOOS: "linux", GOARCH: "arm64"}.ParseSources(platformSources)
+		require.NoError(t, err)
+		structs := structsByName(t, output)
+		require.Equal(t, "linux && arm64", structs["ARM"].Constraint)
+	})
+
+	t.Run("Linux386", func(t *testing.T) {
+		output, err := ParseOptions{BuildConstraints: true, GOOS: "linux", GOARCH: "386"}.ParseSources(platformSources)
+		require.NoError(t, err)
+		structs := structsByName(t, output)
+		require.Equal(t, "linux && 386", structs["Legacy"].Constraint)
+	})
+}
+
+func TestFileConstraint(t *testing.T) {
+	for name, tc := range map[string]struct {
+		file, content, expected string
+	}{
+		"none":         {"conn.go", "package conn\n", ""},
+		"stem only":    {"linux.go", "package conn\n", ""},
+		"os suffix":    {"conn_darwin_test.go", "package conn\n", "darwin"},
+		"arch suffix":  {"asm_amd64.go", "package conn\n", "amd64"},
+		"go:build":     {"x.go", "// Copyright\n\n//go:build (linux || darwin) && cgo\n\npackage conn\n", "(linux || darwin) && cgo"},
+		"combined":     {"x_windows.go", "//go:build go1.20\n\npackage conn\n", "go1.20 && windows"},
+		"after clause": {"x.go", "package conn\n\n//go:build linux\n", ""},
+		"block first":  {"x.go", "/* Copyright\n   2024 */\n\n//go:build linux\n\npackage conn\n", "linux"},
+		"plus build":   {"x.go", "// +build linux darwin\n// +build amd64\n\npackage conn\n", "(linux || darwin) && amd64"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, fileConstraint(tc.file, []byte(tc.content)))
+		})
+	}
+}
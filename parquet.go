@@ -0,0 +1,174 @@
+package structparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"strings"
+)
+
+// parquetPrimitives maps Go basic types to a Parquet primitive type and logical annotation.
+var parquetPrimitives = map[string][2]string{
+	"string":  {"binary", "STRING"},
+	"bool":    {"boolean", ""},
+	"int":     {"int64", ""},
+	"int8":    {"int32", "INTEGER(8,true)"},
+	"int16":   {"int32", "INTEGER(16,true)"},
+	"int32":   {"int32", ""},
+	"int64":   {"int64", ""},
+	"uint":    {"int64", "INTEGER(64,false)"},
+	"uint8":   {"int32", "INTEGER(8,false)"},
+	"uint16":  {"int32", "INTEGER(16,false)"},
+	"uint32":  {"int32", "INTEGER(32,false)"},
+	"uint64":  {"int64", "INTEGER(64,false)"},
+	"byte":    {"int32", "INTEGER(8,false)"},
+	"rune":    {"int32", ""},
+	"float32": {"float", ""},
+	"float64": {"double", ""},
+
+	"[]byte":        {"binary", ""},
+	"time.Time":     {"int64", "TIMESTAMP(MILLIS,true)"},
+	"time.Duration": {"int64", ""},
+}
+
+// GenerateParquet produces one Parquet message schema per exported struct, keyed by struct
+// name. Pointer fields are optional, slices use the three-level LIST layout and maps the
+// MAP layout, nested structs become groups. Column names come from the `parquet` tag,
+// either `parquet:"name,optional"` or `parquet:"name=name, type=..."`; `parquet:"-"` skips
+// a field.
+func GenerateParquet(pkg *Package) (map[string][]byte, error) {
+	g := &parquetGenerator{structs: map[string]Struct{}, enums: map[string]string{}}
+	for _, s := range pkg.Structs {
+		g.structs[s.Name] = s
+	}
+	for _, e := range pkg.Enums {
+		g.enums[e.Name] = e.Type
+	}
+
+	schemas := map[string][]byte{}
+	for _, s := range pkg.Structs {
+		if !ast.IsExported(s.Name) {
+			continue
+		}
+		var buf bytes.Buffer
+		fmt.Fprintf(&buf, "message %s {\n", s.Name)
+		if err := g.fields(&buf, s, "  ", map[string]bool{}); err != nil {
+			return nil, err
+		}
+		buf.WriteString("}\n")
+		schemas[s.Name] = buf.Bytes()
+	}
+	return schemas, nil
+}
+
+type parquetGenerator struct {
+	structs map[string]Struct
+	enums   map[string]string // Enum name to underlying type
+}
+
+func (g *parquetGenerator) fields(buf *bytes.Buffer, s Struct, indent string, stack map[string]bool) error {
+	if stack[s.Name] {
+		return fmt.Errorf("%s: recursive types cannot be represented in parquet", s.Name)
+	}
+	stack[s.Name] = true
+	defer delete(stack, s.Name)
+
+	for _, f := range s.Fields {
+		if f.Name == "" {
+			f.Name = embeddedTypeName(f)
+		}
+		if f.Private {
+			continue
+		}
+		name, optional, ok := parquetColumn(f)
+		if !ok {
+			continue
+		}
+		repetition := "required"
+		if optional || f.Pointer {
+			repetition = "optional"
+		}
+		if err := g.node(buf, indent, repetition, name, parseTypeString(strings.TrimPrefix(f.Type, "*")), stack); err != nil {
+			return fmt.Errorf("%s.%s: %w", s.Name, f.Name, err)
+		}
+	}
+	return nil
+}
+
+// node writes the schema node for a value of the given type.
+func (g *parquetGenerator) node(buf *bytes.Buffer, indent, repetition, name string, expr ast.Expr, stack map[string]bool) error {
+	if expr == nil {
+		return fmt.Errorf("unsupported type")
+	}
+	typeString := justTypeString(getType(expr))
+	if underlying, ok := g.enums[typeString]; ok {
+		typeString = underlying
+	}
+	if primitive, ok := parquetPrimitives[typeString]; ok {
+		fmt.Fprintf(buf, "%s%s %s %s", indent, repetition, primitive[0], name)
+		if primitive[1] != "" {
+			fmt.Fprintf(buf, " (%s)", primitive[1])
+		}
+		buf.WriteString(";\n")
+		return nil
+	}
+
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		return g.node(buf, indent, "optional", name, e.X, stack)
+	case *ast.ArrayType:
+		fmt.Fprintf(buf, "%s%s group %s (LIST) {\n%s  repeated group list {\n", indent, repetition, name, indent)
+		if err := g.node(buf, indent+"    ", "required", "element", e.Elt, stack); err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s  }\n%s}\n", indent, indent)
+		return nil
+	case *ast.MapType:
+		fmt.Fprintf(buf, "%s%s group %s (MAP) {\n%s  repeated group key_value {\n", indent, repetition, name, indent)
+		if err := g.node(buf, indent+"    ", "required", "key", e.Key, stack); err != nil {
+			return err
+		}
+		if err := g.node(buf, indent+"    ", "required", "value", e.Value, stack); err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "%s  }\n%s}\n", indent, indent)
+		return nil
+	case *ast.Ident:
+		if s, ok := g.structs[e.Name]; ok {
+			fmt.Fprintf(buf, "%s%s group %s {\n", indent, repetition, name)
+			if err := g.fields(buf, s, indent+"  ", stack); err != nil {
+				return err
+			}
+			fmt.Fprintf(buf, "%s}\n", indent)
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported type %s", typeString)
+}
+
+// parquetColumn returns the column name and whether the tag marks it optional.
+func parquetColumn(f Field) (name string, optional, ok bool) {
+	tagName, options, tagged := lookupTag(f.Tag, "parquet")
+	if !tagged {
+		return f.Name, false, true
+	}
+	if tagName == "-" {
+		return "", false, false
+	}
+	name = f.Name
+	for i, part := range append([]string{tagName}, options...) {
+		part = strings.TrimSpace(part)
+		key, value, hasValue := strings.Cut(part, "=")
+		switch {
+		case hasValue && key == "name":
+			name = value
+		case hasValue && key == "repetitiontype":
+			optional = strings.EqualFold(value, "optional")
+		case part == "optional":
+			optional = true
+		case i == 0 && part != "":
+			name = part
+		}
+	}
+	return name, optional, true
+}
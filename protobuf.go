@@ -0,0 +1,371 @@
+package structparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ProtoOptions configures GenerateProto.
+type ProtoOptions struct {
+	// Package is the proto package name. Defaults to the Go package name.
+	Package string
+	// GoPackage sets the go_package option when not empty.
+	GoPackage string
+}
+
+// protoScalars maps Go basic types to proto3 scalar types.
+var protoScalars = map[string]string{
+	"string":  "string",
+	"bool":    "bool",
+	"int":     "int64",
+	"int8":    "int32",
+	"int16":   "int32",
+	"int32":   "int32",
+	"int64":   "int64",
+	"uint":    "uint64",
+	"uint8":   "uint32",
+	"uint16":  "uint32",
+	"uint32":  "uint32",
+	"uint64":  "uint64",
+	"uintptr": "uint64",
+	"byte":    "uint32",
+	"rune":    "int32",
+	"float32": "float",
+	"float64": "double",
+}
+
+// protoWellKnown maps Go types to well-known proto types and the file that declares them.
+var protoWellKnown = map[string][2]string{
+	"time.Time":       {"google.protobuf.Timestamp", "google/protobuf/timestamp.proto"},
+	"time.Duration":   {"google.protobuf.Duration", "google/protobuf/duration.proto"},
+	"json.RawMessage": {"google.protobuf.Value", "google/protobuf/struct.proto"},
+}
+
+const protoAny = "google.protobuf.Any"
+
+// GenerateProto produces a proto3 file with a message for each struct, an enum for each
+// detected enum and a service for each interface whose methods follow the
+// (context.Context, *Req) (*Resp, error) shape.
+//
+// Field numbers come from the `protobuf` tag when present (either a bare number or the
+// protoc-gen-go form "bytes,3,opt,name=foo"); remaining fields are numbered in
+// declaration order, skipping numbers already claimed by tags. Unexported fields and
+// those tagged `protobuf:"-"` are left out and take no number.
+func GenerateProto(pkg *Package, opts ProtoOptions) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = pkg.Package
+	}
+
+	g := &protoGenerator{
+		messages: map[string]bool{},
+		enums:    map[string]bool{},
+		imports:  map[string]bool{},
+	}
+	for _, s := range pkg.Structs {
+		g.messages[s.Name] = true
+	}
+	for _, e := range pkg.Enums {
+		g.enums[e.Name] = true
+	}
+
+	var body bytes.Buffer
+	for _, e := range pkg.Enums {
+		g.writeEnum(&body, e)
+	}
+	for _, s := range pkg.Structs {
+		if err := g.writeMessage(&body, s); err != nil {
+			return nil, err
+		}
+	}
+	for _, i := range pkg.Interfaces {
+		g.writeService(&body, i)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by structparser. DO NOT EDIT.\n\n")
+	buf.WriteString("syntax = \"proto3\";\n\n")
+	fmt.Fprintf(&buf, "package %s;\n\n", opts.Package)
+	imports := make([]string, 0, len(g.imports))
+	for k := range g.imports {
+		imports = append(imports, k)
+	}
+	sort.Strings(imports)
+	for _, k := range imports {
+		fmt.Fprintf(&buf, "import %q;\n", k)
+	}
+	if len(imports) > 0 {
+		buf.WriteString("\n")
+	}
+	if opts.GoPackage != "" {
+		fmt.Fprintf(&buf, "option go_package = %q;\n\n", opts.GoPackage)
+	}
+	buf.Write(bytes.TrimRight(body.Bytes(), "\n"))
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+type protoGenerator struct {
+	messages map[string]bool // Structs declared in the package
+	enums    map[string]bool // Enums declared in the package
+	imports  map[string]bool // Proto files the generated types depend on
+}
+
+func (g *protoGenerator) writeEnum(buf *bytes.Buffer, e Enum) {
+	writeProtoDocs(buf, "", e.Docs)
+	fmt.Fprintf(buf, "enum %s {\n", e.Name)
+	prefix := toScreamingSnake(e.Name) + "_"
+
+	numbers := make([]int64, len(e.Values))
+	hasZero := false
+	for i, v := range e.Values {
+		numbers[i] = int64(i + 1)
+		if e.Type != "string" {
+			numbers[i], _ = strconv.ParseInt(v.Value, 0, 64)
+		}
+		hasZero = hasZero || numbers[i] == 0
+	}
+	// proto3 enums must start with a zero value
+	if !hasZero {
+		fmt.Fprintf(buf, "  %sUNSPECIFIED = 0;\n", prefix)
+	}
+	for i, v := range e.Values {
+		writeProtoDocs(buf, "  ", v.Docs)
+		name := toScreamingSnake(v.Name)
+		if !strings.HasPrefix(name, prefix) {
+			name = prefix + name
+		}
+		fmt.Fprintf(buf, "  %s = %d;\n", name, numbers[i])
+	}
+	buf.WriteString("}\n\n")
+}
+
+func (g *protoGenerator) writeMessage(buf *bytes.Buffer, s Struct) error {
+	// Private and skipped fields are left out before numbering, so adding one does not
+	// renumber the fields after it
+	fields := make([]Field, 0, len(s.Fields))
+	for _, f := range s.Fields {
+		if f.Name == "" {
+			f.Name = embeddedTypeName(f)
+		}
+		if f.Private {
+			continue
+		}
+		if name, _, _ := lookupTag(f.Tag, "protobuf"); name == "-" {
+			continue
+		}
+		fields = append(fields, f)
+	}
+
+	used := map[int]bool{}
+	numbers := make([]int, len(fields))
+	for i, f := range fields {
+		if n := protoFieldNumber(f.Tag); n > 0 {
+			if used[n] {
+				return fmt.Errorf("%s.%s: duplicate protobuf field number %d", s.Name, f.Name, n)
+			}
+			numbers[i] = n
+			used[n] = true
+		}
+	}
+	next := 1
+	for i := range fields {
+		if numbers[i] > 0 {
+			continue
+		}
+		for used[next] {
+			next++
+		}
+		numbers[i] = next
+		used[next] = true
+	}
+
+	writeProtoDocs(buf, "", s.Docs)
+	fmt.Fprintf(buf, "message %s {\n", s.Name)
+	for i, f := range fields {
+		typ, err := g.fieldType(parseTypeString(f.Type))
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", s.Name, f.Name, err)
+		}
+		docs := append([]string{}, f.Docs...)
+		if f.Comment != "" {
+			docs = append(docs, f.Comment)
+		}
+		writeProtoDocs(buf, "  ", docs)
+		fmt.Fprintf(buf, "  %s %s = %d;\n", typ, protoFieldName(f), numbers[i])
+	}
+	buf.WriteString("}\n\n")
+	return nil
+}
+
+func (g *protoGenerator) writeService(buf *bytes.Buffer, i Interface) {
+	rpcs := []string{}
+	for _, m := range i.Methods {
+		if len(m.Params) != 2 || m.Params[0].Type != "context.Context" || len(m.Returns) != 2 || m.Returns[1].Type != "error" {
+			continue
+		}
+		req, resp := protoMessageRef(m.Params[1].Type), protoMessageRef(m.Returns[0].Type)
+		if req == "" || resp == "" {
+			continue
+		}
+		var rpc bytes.Buffer
+		writeProtoDocs(&rpc, "  ", m.Docs)
+		fmt.Fprintf(&rpc, "  rpc %s(%s) returns (%s);\n", m.Name, req, resp)
+		rpcs = append(rpcs, rpc.String())
+	}
+	if len(rpcs) == 0 {
+		return
+	}
+	writeProtoDocs(buf, "", i.Docs)
+	fmt.Fprintf(buf, "service %s {\n%s}\n\n", i.Name, strings.Join(rpcs, ""))
+}
+
+// fieldType maps a Go field type to a proto field type including its label.
+func (g *protoGenerator) fieldType(expr ast.Expr) (string, error) {
+	switch e := expr.(type) {
+	case *ast.StarExpr:
+		typ, err := g.fieldType(e.X)
+		if err != nil || strings.Contains(typ, " ") || strings.HasPrefix(typ, "map<") {
+			return typ, err
+		}
+		if _, ok := protoScalarOrEnum(typ, g.enums); ok {
+			// Pointers to scalars keep track of presence
+			return "optional " + typ, nil
+		}
+		return typ, nil
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") {
+			return "bytes", nil
+		}
+		elem, err := g.fieldType(e.Elt)
+		if err != nil {
+			return "", err
+		}
+		// Elements of repeated fields have no presence to keep track of
+		elem = strings.TrimPrefix(elem, "optional ")
+		if strings.Contains(elem, " ") || strings.HasPrefix(elem, "map<") {
+			return "", fmt.Errorf("unsupported nested collection %s", justTypeString(getType(e)))
+		}
+		return "repeated " + elem, nil
+	case *ast.MapType:
+		key, err := g.fieldType(e.Key)
+		if err != nil {
+			return "", err
+		}
+		if _, ok := protoScalars[justTypeString(getType(e.Key))]; !ok || key == "float" || key == "double" {
+			return "", fmt.Errorf("unsupported map key %s", justTypeString(getType(e.Key)))
+		}
+		value, err := g.fieldType(e.Value)
+		if err != nil {
+			return "", err
+		}
+		value = strings.TrimPrefix(value, "optional ")
+		if strings.Contains(value, " ") || strings.HasPrefix(value, "map<") {
+			return "", fmt.Errorf("unsupported map value %s", justTypeString(getType(e.Value)))
+		}
+		return fmt.Sprintf("map<%s, %s>", key, value), nil
+	case *ast.Ident:
+		if scalar, ok := protoScalars[e.Name]; ok {
+			return scalar, nil
+		}
+		if g.messages[e.Name] || g.enums[e.Name] {
+			return e.Name, nil
+		}
+	case *ast.SelectorExpr:
+		qualified := justTypeString(getType(e))
+		if wk, ok := protoWellKnown[qualified]; ok {
+			g.imports[wk[1]] = true
+			return wk[0], nil
+		}
+		if pkg, ok := e.X.(*ast.Ident); ok {
+			g.imports[pkg.Name+".proto"] = true
+		}
+		return qualified, nil
+	}
+	g.imports["google/protobuf/any.proto"] = true
+	return protoAny, nil
+}
+
+func protoScalarOrEnum(typ string, enums map[string]bool) (string, bool) {
+	for _, scalar := range protoScalars {
+		if scalar == typ {
+			return typ, true
+		}
+	}
+	return typ, enums[typ]
+}
+
+// protoMessageRef returns the message name for a *Req or *pkg.Req parameter type.
+func protoMessageRef(typeString string) string {
+	if !strings.HasPrefix(typeString, "*") {
+		return ""
+	}
+	name := strings.TrimPrefix(typeString, "*")
+	if strings.ContainsAny(name, "[]*") {
+		return ""
+	}
+	return name
+}
+
+// protoFieldNumber reads the field number from a `protobuf:"3"` or
+// `protobuf:"varint,3,opt,name=foo"` tag. It returns 0 when there is none.
+func protoFieldNumber(tag string) int {
+	name, options, ok := lookupTag(tag, "protobuf")
+	if !ok {
+		return 0
+	}
+	for _, part := range append([]string{name}, options...) {
+		if n, err := strconv.Atoi(part); err == nil && n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+// protoFieldName returns the `name=` option of the protobuf tag, the json name, or the
+// snake_case Go name.
+func protoFieldName(f Field) string {
+	name, options, _ := lookupTag(f.Tag, "protobuf")
+	for _, part := range append([]string{name}, options...) {
+		if strings.HasPrefix(part, "name=") {
+			return strings.TrimPrefix(part, "name=")
+		}
+	}
+	if name, _, ok := jsonName(f); ok && name != f.Name {
+		return name
+	}
+	return toSnake(f.Name)
+}
+
+func writeProtoDocs(buf *bytes.Buffer, indent string, docs []string) {
+	for _, d := range docs {
+		fmt.Fprintf(buf, "%s// %s\n", indent, d)
+	}
+}
+
+// toSnake converts a Go identifier to snake_case, keeping initialisms together
+// (e.g., "UserID" becomes "user_id").
+func toSnake(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (prevLower || nextLower && unicode.IsUpper(runes[i-1])) {
+				b.WriteRune('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func toScreamingSnake(name string) string {
+	return strings.ToUpper(toSnake(name))
+}
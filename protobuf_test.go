@@ -0,0 +1,89 @@
+package structparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateProto(t *testing.T) {
+	code := `
+	package users
+
+	import (
+		"context"
+		"time"
+	)
+
+	type Status int
+
+	const (
+		StatusActive Status = iota + 1
+		StatusBanned
+	)
+
+	// User is a person
+	type User struct {
+		UserID    string            ` + "`protobuf:\"2\"`" + `
+		Name      string
+		Nickname  *string
+		Status    Status
+		Roles     []string
+		Avatar    []byte
+		Meta      map[string]int64
+		CreatedAt time.Time
+		Friends   []*User           ` + "`protobuf:\"bytes,10,rep,name=friend_list\"`" + `
+	}
+
+	type GetUserRequest struct {
+		ID string ` + "`json:\"id\"`" + `
+	}
+
+	// UserService manages users
+	type UserService interface {
+		// GetUser returns a user
+		GetUser(ctx context.Context, req *GetUserRequest) (*User, error)
+		Close() error
+	}
+	`
+	output, err := ParseString(code)
+	require.NoError(t, err)
+
+	proto, err := GenerateProto(&output.Packages[0], ProtoOptions{GoPackage: "example.com/users"})
+	require.NoError(t, err)
+	got := string(proto)
+
+	require.Contains(t, got, "syntax = \"proto3\";\n\npackage users;")
+	require.Contains(t, got, `import "google/protobuf/timestamp.proto";`)
+	require.Contains(t, got, `option go_package = "example.com/users";`)
+	require.Contains(t, got, "enum Status {\n  STATUS_UNSPECIFIED = 0;\n  STATUS_ACTIVE = 1;\n  STATUS_BANNED = 2;\n}")
+	require.Contains(t, got, "// User is a person\nmessage User {\n  string user_id = 2;\n  string name = 1;\n  optional string nickname = 3;\n  Status status = 4;\n  repeated string roles = 5;\n  bytes avatar = 6;\n  map<string, int64> meta = 7;\n  google.protobuf.Timestamp created_at = 8;\n  repeated User friend_list = 10;\n}")
+	require.Contains(t, got, "message GetUserRequest {\n  string id = 1;\n}")
+	require.Contains(t, got, "// UserService manages users\nservice UserService {\n  // GetUser returns a user\n  rpc GetUser(GetUserRequest) returns (User);\n}")
+	require.NotContains(t, got, "Close")
+}
+
+func TestGenerateProtoStableNumbers(t *testing.T) {
+	numbers := func(fields string) string {
+		output, err := ParseString("package users\n\nimport \"sync\"\n\ntype User struct {\n" + fields + "\n}\n")
+		require.NoError(t, err)
+		proto, err := GenerateProto(&output.Packages[0], ProtoOptions{})
+		require.NoError(t, err)
+		got := string(proto)
+		return got[strings.Index(got, "message User"):]
+	}
+	before := numbers("Name string\nScores []*int\nEmail string")
+	require.Equal(t, "message User {\n  string name = 1;\n  repeated int64 scores = 2;\n  string email = 3;\n}\n", before)
+
+	// Private and skipped fields do not take a number
+	after := numbers("mu sync.Mutex\nName string\nInternal string `protobuf:\"-\"`\nScores []*int\nEmail string")
+	require.Equal(t, before, after)
+}
+
+func TestToSnake(t *testing.T) {
+	require.Equal(t, "user_id", toSnake("UserID"))
+	require.Equal(t, "http_server", toSnake("HTTPServer"))
+	require.Equal(t, "created_at", toSnake("CreatedAt"))
+	require.Equal(t, "v2_name", toSnake("V2Name"))
+}
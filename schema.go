@@ -0,0 +1,147 @@
+package structparser
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+//go:generate go test -run TestOutputSchema|TestGoldenOutput -update .
+
+//go:embed schema/output.schema.json
+var outputSchema []byte
+
+// OutputSchema returns the JSON Schema (draft 2020-12) describing the JSON encoding of
+// Output for the current SchemaVersion.
+func OutputSchema() []byte {
+	return append([]byte(nil), outputSchema...)
+}
+
+type jsonSchemaNode struct {
+	Schema               string          `json:"$schema,omitempty"`
+	Title                string          `json:"title,omitempty"`
+	Description          string          `json:"description,omitempty"`
+	Ref                  string          `json:"$ref,omitempty"`
+	Type                 string          `json:"type,omitempty"`
+	Const                string          `json:"const,omitempty"`
+	Items                *jsonSchemaNode `json:"items,omitempty"`
+	Properties           orderedMap      `json:"properties,omitempty"`
+	Required             []string        `json:"required,omitempty"`
+	AdditionalProperties *bool           `json:"additionalProperties,omitempty"`
+	Defs                 orderedMap      `json:"$defs,omitempty"`
+}
+
+// buildOutputSchema derives the schema from the Output types by reflection. docs holds
+// descriptions keyed by type name ("Struct") and by type and field name ("Struct.Name");
+// they are read from the Go source, which is not available at run time.
+func buildOutputSchema(docs map[string]string) ([]byte, error) {
+	b := &schemaBuilder{docs: docs, seen: map[string]bool{}}
+	root, err := b.object(reflect.TypeOf(Output{}))
+	if err != nil {
+		return nil, err
+	}
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.Title = "structparser output, schema version " + SchemaVersion
+	root.Properties[0].Value.(*jsonSchemaNode).Const = SchemaVersion
+	root.Defs = b.defs
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(root); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type schemaBuilder struct {
+	docs map[string]string
+	seen map[string]bool
+	defs orderedMap
+}
+
+func (b *schemaBuilder) object(t reflect.Type) (*jsonSchemaNode, error) {
+	no := false
+	node := &jsonSchemaNode{
+		Description:          b.docs[t.Name()],
+		Type:                 "object",
+		Properties:           orderedMap{},
+		AdditionalProperties: &no,
+	}
+	if err := b.properties(node, t); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// properties adds the fields of t to node, inlining embedded structs like encoding/json.
+func (b *schemaBuilder) properties(node *jsonSchemaNode, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		parts := strings.Split(f.Tag.Get("json"), ",")
+		name := parts[0]
+		if name == "-" || f.PkgPath != "" {
+			continue
+		}
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			if err := b.properties(node, f.Type); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		property, err := b.typeOf(f.Type)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+		}
+		// Keywords next to $ref are allowed since draft 2019-09
+		property.Description = b.docs[t.Name()+"."+f.Name]
+		node.Properties = append(node.Properties, orderedEntry{Key: name, Value: property})
+		if !hasTagOption(parts[1:], "omitempty") {
+			node.Required = append(node.Required, name)
+		}
+	}
+	return nil
+}
+
+func (b *schemaBuilder) typeOf(t reflect.Type) (*jsonSchemaNode, error) {
+	switch t.Kind() {
+	case reflect.String:
+		return &jsonSchemaNode{Type: "string"}, nil
+	case reflect.Bool:
+		return &jsonSchemaNode{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchemaNode{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchemaNode{Type: "number"}, nil
+	case reflect.Ptr:
+		return b.typeOf(t.Elem())
+	case reflect.Slice:
+		items, err := b.typeOf(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchemaNode{Type: "array", Items: items}, nil
+	case reflect.Struct:
+		if !b.seen[t.Name()] {
+			b.seen[t.Name()] = true
+			// Reserve the slot first so definitions are listed in the order they are used
+			b.defs = append(b.defs, orderedEntry{Key: t.Name()})
+			slot := len(b.defs) - 1
+			def, err := b.object(t)
+			if err != nil {
+				return nil, err
+			}
+			b.defs[slot].Value = def
+		}
+		return &jsonSchemaNode{Ref: "#/$defs/" + t.Name()}, nil
+	}
+	return nil, fmt.Errorf("unsupported kind %s", t.Kind())
+}
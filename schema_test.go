@@ -0,0 +1,56 @@
+package structparser
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite schema/output.schema.json and the golden files in testdata")
+
+// checkGolden compares got with the content of path, or rewrites path when -update is set.
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(got), "output changed, run `go generate` if the change is intended")
+}
+
+func TestOutputSchema(t *testing.T) {
+	source, err := ParseFile("structparser.go")
+	require.NoError(t, err)
+	docs := map[string]string{}
+	for _, s := range source.Packages[0].Structs {
+		docs[s.Name] = strings.Join(s.Docs, " ")
+		for _, f := range s.Fields {
+			docs[s.Name+"."+f.Name] = strings.TrimSpace(strings.Join(append(f.Docs, f.Comment), " "))
+		}
+	}
+
+	schema, err := buildOutputSchema(docs)
+	require.NoError(t, err)
+	checkGolden(t, "schema/output.schema.json", schema)
+	if !*update {
+		require.Equal(t, schema, OutputSchema())
+	}
+}
+
+func TestGoldenOutput(t *testing.T) {
+	output, err := ParseDirectory("./example")
+	require.NoError(t, err)
+	require.Equal(t, SchemaVersion, output.SchemaVersion)
+
+	encoded, err := json.MarshalIndent(output, "", "  ")
+	require.NoError(t, err)
+	checkGolden(t, "testdata/example.golden.json", append(encoded, '\n'))
+}
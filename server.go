@@ -0,0 +1,247 @@
+package structparser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// PackageSummary lists the entities of a package by name.
+type PackageSummary struct {
+	Package    string   `json:"package"`
+	Path       string   `json:"path,omitempty"`
+	Structs    []string `json:"structs,omitempty"`
+	Interfaces []string `json:"interfaces,omitempty"`
+	Functions  []string `json:"functions,omitempty"`
+	Enums      []string `json:"enums,omitempty"`
+}
+
+// NewServer returns an HTTP handler serving the current state of ws as JSON:
+//
+//	GET /packages                        a PackageSummary per package
+//	GET /structs/{pkg}/{name}            a Struct; pkg is a package name or import path
+//	GET /interfaces/{name}/implementors  the structs implementing an interface, as Entities;
+//	                                     name may be qualified ("pkg.Name")
+//	GET /search?q=text                   the entities and methods whose name contains text,
+//	                                     ignoring case
+//
+// Every response has an ETag computed from its body, and a request whose If-None-Match
+// matches gets 304 Not Modified. The workspace can be updated while serving, for
+// instance by Workspace.Watch.
+func NewServer(ws *Workspace) http.Handler {
+	s := &server{ws: ws}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/packages", s.packages)
+	mux.HandleFunc("/structs/", s.structs)
+	mux.HandleFunc("/interfaces/", s.implementors)
+	mux.HandleFunc("/search", s.search)
+	return mux
+}
+
+type server struct {
+	ws *Workspace
+}
+
+func (s *server) packages(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	summaries := []PackageSummary{}
+	for _, pkg := range s.ws.Output().Packages {
+		summary := PackageSummary{Package: pkg.Package, Path: pkg.Path}
+		for _, x := range pkg.Structs {
+			summary.Structs = append(summary.Structs, x.Name)
+		}
+		for _, x := range pkg.Interfaces {
+			summary.Interfaces = append(summary.Interfaces, x.Name)
+		}
+		for _, x := range pkg.Functions {
+			summary.Functions = append(summary.Functions, x.Name)
+		}
+		for _, x := range pkg.Enums {
+			summary.Enums = append(summary.Enums, x.Name)
+		}
+		summaries = append(summaries, summary)
+	}
+	writeJSON(w, r, summaries)
+}
+
+func (s *server) structs(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/structs/")
+	slash := strings.LastIndex(rest, "/")
+	if slash <= 0 || slash == len(rest)-1 {
+		writeError(w, http.StatusNotFound, "expected /structs/{pkg}/{name}")
+		return
+	}
+	pkgName, name := rest[:slash], rest[slash+1:]
+	for _, pkg := range s.ws.Output().Packages {
+		if pkg.Package != pkgName && pkg.Path != pkgName {
+			continue
+		}
+		for _, st := range pkg.Structs {
+			if st.Name == name {
+				writeJSON(w, r, st)
+				return
+			}
+		}
+	}
+	writeError(w, http.StatusNotFound, "struct "+pkgName+"."+name+" not found")
+}
+
+func (s *server) implementors(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/interfaces/")
+	if !strings.HasSuffix(name, "/implementors") {
+		writeError(w, http.StatusNotFound, "expected /interfaces/{name}/implementors")
+		return
+	}
+	name = strings.TrimSuffix(name, "/implementors")
+	implementors, ok := findImplementors(s.ws.Output(), name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "interface "+name+" not found")
+		return
+	}
+	writeJSON(w, r, implementors)
+}
+
+func (s *server) search(w http.ResponseWriter, r *http.Request) {
+	if !allowGet(w, r) {
+		return
+	}
+	q := strings.ToLower(r.URL.Query().Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "missing q parameter")
+		return
+	}
+	found := []Entity{}
+	add := func(pkg Package, kind, name string, pos Position) {
+		if strings.Contains(strings.ToLower(name), q) {
+			found = append(found, Entity{Package: entityPackage(pkg), Kind: kind, Name: name, Position: pos})
+		}
+	}
+	for _, pkg := range s.ws.Output().Packages {
+		for _, x := range pkg.Structs {
+			add(pkg, "struct", x.Name, x.Position)
+			for _, m := range x.Methods {
+				add(pkg, "method", x.Name+"."+m.Name, m.Position)
+			}
+		}
+		for _, x := range pkg.Interfaces {
+			add(pkg, "interface", x.Name, x.Position)
+		}
+		for _, x := range pkg.Functions {
+			add(pkg, "function", x.Name, x.Position)
+		}
+		for _, x := range pkg.Variables {
+			add(pkg, "variable", x.Name, x.Position)
+		}
+		for _, x := range pkg.Constants {
+			add(pkg, "constant", x.Name, x.Position)
+		}
+		for _, x := range pkg.Enums {
+			add(pkg, "enum", x.Name, x.Position)
+		}
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Package != found[j].Package {
+			return found[i].Package < found[j].Package
+		}
+		return found[i].Name < found[j].Name
+	})
+	writeJSON(w, r, found)
+}
+
+// findImplementors returns the structs implementing the interfaces called name, which may
+// be qualified by package name or import path ("pkg.Name"). It reports false when there
+// is no such interface.
+func findImplementors(output *Output, name string) ([]Entity, bool) {
+	qualifier := ""
+	if dot := strings.LastIndex(name, "."); dot >= 0 {
+		qualifier, name = name[:dot], name[dot+1:]
+	}
+	var (
+		ifaces    []Interface
+		ifacePkgs []Package
+	)
+	for _, pkg := range output.Packages {
+		if qualifier != "" && pkg.Package != qualifier && pkg.Path != qualifier {
+			continue
+		}
+		for _, i := range pkg.Interfaces {
+			if i.Name == name {
+				ifaces = append(ifaces, i)
+				ifacePkgs = append(ifacePkgs, pkg)
+			}
+		}
+	}
+	if len(ifaces) == 0 {
+		return nil, false
+	}
+
+	types := newTypeTable(output)
+	implementors := []Entity{}
+	for _, pkg := range output.Packages {
+		for _, st := range pkg.Structs {
+			for k, i := range ifaces {
+				if types.implements(pkg, st, ifacePkgs[k], i) {
+					implementors = append(implementors, Entity{Package: entityPackage(pkg), Kind: "struct", Name: st.Name, Position: st.Position})
+					break
+				}
+			}
+		}
+	}
+	return implementors, true
+}
+
+func allowGet(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", "GET, HEAD")
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+// writeJSON writes v with an ETag, or 304 Not Modified when the client has it already.
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(buf.Bytes())
+}
+
+func matchesETag(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
@@ -0,0 +1,98 @@
+package structparser
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "store.go"), []byte(`package store
+
+type Saver interface {
+	Save() error
+}
+
+type User struct {
+	Name string
+}
+
+func (u *User) Save() error { return nil }
+
+type Order struct{}
+
+func NewUser() int { return 0 }
+`), 0o644))
+	ws := NewWorkspace(ParseOptions{})
+	_, err := ws.Load(context.Background(), dir)
+	require.NoError(t, err)
+	server := httptest.NewServer(NewServer(ws))
+	defer server.Close()
+
+	get := func(path string, v interface{}) *http.Response {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		if v != nil && resp.StatusCode == http.StatusOK {
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(v))
+		}
+		return resp
+	}
+
+	var packages []PackageSummary
+	get("/packages", &packages)
+	require.Equal(t, []PackageSummary{{Package: "store", Structs: []string{"Order", "User"}, Interfaces: []string{"Saver"}, Functions: []string{"NewUser"}}}, packages)
+
+	var user Struct
+	resp := get("/structs/store/User", &user)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "Name", user.Fields[0].Name)
+	require.Equal(t, http.StatusNotFound, get("/structs/store/Missing", nil).StatusCode)
+	require.Equal(t, http.StatusNotFound, get("/structs/User", nil).StatusCode)
+
+	var implementors []Entity
+	get("/interfaces/store.Saver/implementors", &implementors)
+	require.Len(t, implementors, 1)
+	require.Equal(t, "User", implementors[0].Name)
+	require.Equal(t, http.StatusNotFound, get("/interfaces/Missing/implementors", nil).StatusCode)
+
+	var found []Entity
+	get("/search?q=user", &found)
+	var names []string
+	for _, e := range found {
+		names = append(names, e.Kind+" "+e.Name)
+	}
+	require.Equal(t, []string{"function NewUser", "struct User", "method User.Save"}, names)
+	require.Equal(t, http.StatusBadRequest, get("/search", nil).StatusCode)
+
+	// ETags follow the content
+	resp = get("/packages", nil)
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	request, _ := http.NewRequest(http.MethodGet, server.URL+"/packages", nil)
+	request.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	_, err = ws.UpdateFile(filepath.Join(dir, "item.go"), []byte("package store\n\ntype Item struct{}\n"))
+	require.NoError(t, err)
+	resp, err = http.DefaultClient.Do(request)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NotEqual(t, etag, resp.Header.Get("ETag"))
+
+	resp, err = http.Post(server.URL+"/packages", "application/json", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
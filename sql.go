@@ -0,0 +1,314 @@
+package structparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"strconv"
+	"strings"
+)
+
+// SQLDialect selects the flavor of DDL produced by GenerateSQL.
+type SQLDialect string
+
+const (
+	PostgreSQL SQLDialect = "postgres"
+	MySQL      SQLDialect = "mysql"
+	SQLite     SQLDialect = "sqlite"
+)
+
+// SQLOptions configures GenerateSQL.
+type SQLOptions struct {
+	// Dialect defaults to PostgreSQL.
+	Dialect SQLDialect
+	// TableName returns the table name for a struct. Defaults to the snake_case struct name.
+	TableName func(s Struct) string
+}
+
+// sqlColumnTypes maps Go types to column types per dialect, in PostgreSQL, MySQL, SQLite order.
+var sqlColumnTypes = map[string][3]string{
+	"string":          {"TEXT", "VARCHAR(255)", "TEXT"},
+	"bool":            {"BOOLEAN", "BOOLEAN", "INTEGER"},
+	"int":             {"BIGINT", "BIGINT", "INTEGER"},
+	"int8":            {"SMALLINT", "TINYINT", "INTEGER"},
+	"int16":           {"SMALLINT", "SMALLINT", "INTEGER"},
+	"int32":           {"INTEGER", "INT", "INTEGER"},
+	"int64":           {"BIGINT", "BIGINT", "INTEGER"},
+	"uint":            {"BIGINT", "BIGINT UNSIGNED", "INTEGER"},
+	"uint8":           {"SMALLINT", "TINYINT UNSIGNED", "INTEGER"},
+	"uint16":          {"INTEGER", "SMALLINT UNSIGNED", "INTEGER"},
+	"uint32":          {"BIGINT", "INT UNSIGNED", "INTEGER"},
+	"uint64":          {"NUMERIC(20)", "BIGINT UNSIGNED", "INTEGER"},
+	"byte":            {"SMALLINT", "TINYINT UNSIGNED", "INTEGER"},
+	"rune":            {"INTEGER", "INT", "INTEGER"},
+	"float32":         {"REAL", "FLOAT", "REAL"},
+	"float64":         {"DOUBLE PRECISION", "DOUBLE", "REAL"},
+	"[]byte":          {"BYTEA", "BLOB", "BLOB"},
+	"time.Time":       {"TIMESTAMPTZ", "DATETIME", "DATETIME"},
+	"time.Duration":   {"BIGINT", "BIGINT", "INTEGER"},
+	"json.RawMessage": {"JSONB", "JSON", "TEXT"},
+	"uuid.UUID":       {"UUID", "CHAR(36)", "TEXT"},
+	"sql.NullString":  {"TEXT", "VARCHAR(255)", "TEXT"},
+	"sql.NullBool":    {"BOOLEAN", "BOOLEAN", "INTEGER"},
+	"sql.NullInt16":   {"SMALLINT", "SMALLINT", "INTEGER"},
+	"sql.NullInt32":   {"INTEGER", "INT", "INTEGER"},
+	"sql.NullInt64":   {"BIGINT", "BIGINT", "INTEGER"},
+	"sql.NullFloat64": {"DOUBLE PRECISION", "DOUBLE", "REAL"},
+	"sql.NullTime":    {"TIMESTAMPTZ", "DATETIME", "DATETIME"},
+	"sql.NullByte":    {"SMALLINT", "TINYINT UNSIGNED", "INTEGER"},
+}
+
+type sqlColumn struct {
+	name     string
+	typ      string
+	nullable bool
+	pk       bool
+	unique   bool
+	index    bool
+	dflt     string
+}
+
+// GenerateSQL produces CREATE TABLE statements for every struct that has at least one
+// `db` tag. Only tagged fields become columns, and fields of embedded structs are
+// flattened. Embedded structs without any `db` tag, such as timestamp mixins, are
+// column-only: all their exported fields become columns, and they get no table of their
+// own. Named types such as "type UserID int64" use the column type of their underlying
+// type. Fields without a column type are left out, with a comment in the table's DDL.
+//
+// Tag options after the column name are supported as in
+// `db:"email,unique,size=320"`: pk, unique, index, default=<expr> and size=<n>.
+// Pointer and sql.Null* fields are NULLable, everything else is NOT NULL.
+func GenerateSQL(out *Output, opts SQLOptions) ([]byte, error) {
+	if opts.Dialect == "" {
+		opts.Dialect = PostgreSQL
+	}
+	if opts.TableName == nil {
+		opts.TableName = func(s Struct) string { return toSnake(s.Name) }
+	}
+	dialect := map[SQLDialect]int{PostgreSQL: 0, MySQL: 1, SQLite: 2}
+	d, ok := dialect[opts.Dialect]
+	if !ok {
+		return nil, fmt.Errorf("unknown SQL dialect %q", opts.Dialect)
+	}
+
+	g := &sqlGenerator{
+		dialect: d,
+		structs: map[string]sqlStruct{},
+		named:   map[string]sqlNamed{},
+	}
+	for _, pkg := range out.Packages {
+		for _, s := range pkg.Structs {
+			g.structs[sqlKey(pkg, s.Name)] = sqlStruct{pkg: pkg, s: s}
+		}
+		for _, t := range pkg.Types {
+			if (t.Kind == "named" || t.Kind == "alias") && t.Underlying != "" {
+				g.named[sqlKey(pkg, t.Name)] = sqlNamed{pkg: pkg, underlying: t.Underlying}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, pkg := range out.Packages {
+		for _, s := range pkg.Structs {
+			var problems []string
+			columns := g.columns(sqlStruct{pkg: pkg, s: s}, false, map[string]bool{}, &problems)
+			if len(columns) == 0 {
+				continue
+			}
+			writeCreateTable(&buf, opts.Dialect, opts.TableName(s), s.Docs, columns, problems)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+type sqlGenerator struct {
+	dialect int
+	structs map[string]sqlStruct // Structs by sqlKey
+	named   map[string]sqlNamed  // Named types other than structs and interfaces by sqlKey
+}
+
+// sqlStruct is a struct with its package, to resolve the type names of its fields.
+type sqlStruct struct {
+	pkg Package
+	s   Struct
+}
+
+// sqlNamed is the underlying type of a named type, as spelled in its package.
+type sqlNamed struct {
+	pkg        Package
+	underlying string
+}
+
+// sqlKey identifies the type name typ used in pkg by import path, so that types of the
+// same name in different packages are told apart.
+func sqlKey(pkg Package, typ string) string {
+	if x, name, ok := strings.Cut(typ, "."); ok {
+		return importPathOf(pkg, x) + "." + name
+	}
+	return entityPackage(pkg) + "." + typ
+}
+
+func hasDBTags(s Struct) bool {
+	for _, f := range s.Fields {
+		if _, _, ok := lookupTag(f.Tag, "db"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve follows named types of the parsed packages to the type a column is made from.
+func (g *sqlGenerator) resolve(pkg Package, typ string) string {
+	for seen := map[string]bool{}; isTypeName(typ); {
+		if _, ok := sqlColumnTypes[typ]; ok {
+			break
+		}
+		key := sqlKey(pkg, typ)
+		n, ok := g.named[key]
+		if !ok || seen[key] {
+			break
+		}
+		seen[key] = true
+		pkg, typ = n.pkg, n.underlying
+	}
+	return typ
+}
+
+// columns returns the columns of the struct, appending to problems a message for each
+// field that cannot be a column. Every exported field of a mixin is a column.
+func (g *sqlGenerator) columns(ss sqlStruct, mixin bool, seen map[string]bool, problems *[]string) []sqlColumn {
+	s := ss.s
+	key := sqlKey(ss.pkg, s.Name)
+	if seen[key] {
+		*problems = append(*problems, fmt.Sprintf("%s: recursive embedding", s.Name))
+		return nil
+	}
+	seen[key] = true
+	defer delete(seen, key)
+
+	columns := []sqlColumn{}
+	for _, f := range s.Fields {
+		name, options, tagged := lookupTag(f.Tag, "db")
+		if name == "-" {
+			continue
+		}
+		if f.Name == "" && name == "" {
+			if embedded, ok := g.structs[sqlKey(ss.pkg, strings.TrimPrefix(f.Type, "*"))]; ok {
+				columns = append(columns, g.columns(embedded, !hasDBTags(embedded.s), seen, problems)...)
+			}
+			continue
+		}
+		if !tagged && !mixin || f.Private || f.Name == "" {
+			continue
+		}
+		if name == "" {
+			name = toSnake(f.Name)
+		}
+
+		column := sqlColumn{name: name}
+		typeString := f.Type
+		if f.Pointer {
+			column.nullable = true
+			typeString = strings.TrimPrefix(typeString, "*")
+		}
+		typeString = g.resolve(ss.pkg, typeString)
+		if strings.HasPrefix(typeString, "sql.Null") {
+			column.nullable = true
+		}
+
+		size, valid := 0, true
+		for _, option := range options {
+			key, value, _ := strings.Cut(option, "=")
+			switch key {
+			case "pk":
+				column.pk = true
+			case "unique":
+				column.unique = true
+			case "index":
+				column.index = true
+			case "default":
+				column.dflt = value
+			case "size":
+				n, err := strconv.Atoi(value)
+				if err != nil || n <= 0 {
+					*problems = append(*problems, fmt.Sprintf("%s.%s: invalid size %q", s.Name, f.Name, value))
+					valid = false
+				}
+				size = n
+			}
+		}
+
+		if types, ok := sqlColumnTypes[typeString]; ok {
+			column.typ = types[g.dialect]
+		} else {
+			switch parseTypeString(typeString).(type) {
+			case *ast.ArrayType, *ast.MapType:
+				column.typ = [3]string{"JSONB", "JSON", "TEXT"}[g.dialect]
+			default:
+				*problems = append(*problems, fmt.Sprintf("%s.%s: no column type for %s", s.Name, f.Name, f.Type))
+				valid = false
+			}
+		}
+		if !valid {
+			continue
+		}
+		if size > 0 && (typeString == "string" || typeString == "sql.NullString") {
+			column.typ = fmt.Sprintf("VARCHAR(%d)", size)
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+func writeCreateTable(buf *bytes.Buffer, dialect SQLDialect, table string, docs []string, columns []sqlColumn, problems []string) {
+	quote := func(name string) string {
+		if dialect == MySQL {
+			return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+		}
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+
+	pks := []string{}
+	for _, c := range columns {
+		if c.pk {
+			pks = append(pks, quote(c.name))
+		}
+	}
+
+	for _, d := range docs {
+		fmt.Fprintf(buf, "-- %s\n", d)
+	}
+	for _, p := range problems {
+		fmt.Fprintf(buf, "-- skipped %s\n", p)
+	}
+	lines := []string{}
+	for _, c := range columns {
+		line := "  " + quote(c.name) + " " + c.typ
+		if c.nullable && !c.pk {
+			line += " NULL"
+		} else {
+			line += " NOT NULL"
+		}
+		if c.dflt != "" {
+			line += " DEFAULT " + c.dflt
+		}
+		if c.pk && len(pks) == 1 {
+			line += " PRIMARY KEY"
+		}
+		if c.unique {
+			line += " UNIQUE"
+		}
+		lines = append(lines, line)
+	}
+	if len(pks) > 1 {
+		lines = append(lines, "  PRIMARY KEY ("+strings.Join(pks, ", ")+")")
+	}
+	fmt.Fprintf(buf, "CREATE TABLE %s (\n%s\n);\n", quote(table), strings.Join(lines, ",\n"))
+
+	for _, c := range columns {
+		if c.index {
+			fmt.Fprintf(buf, "CREATE INDEX %s ON %s (%s);\n", quote("idx_"+table+"_"+c.name), quote(table), quote(c.name))
+		}
+	}
+	buf.WriteString("\n")
+}
@@ -0,0 +1,153 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateSQL(t *testing.T) {
+	output, err := ParseDirectory("./example/simple_struct.go")
+	require.NoError(t, err)
+
+	t.Run("PostgreSQL", func(t *testing.T) {
+		ddl, err := GenerateSQL(output, SQLOptions{})
+		require.NoError(t, err)
+		require.Equal(t, `-- Simple structure is a simple struct
+-- Represents a user record in the database
+CREATE TABLE "simple_struct" (
+  "id" BIGINT NOT NULL,
+  "name" TEXT NOT NULL,
+  "favorite_colors" JSONB NOT NULL,
+  "date_updated" TIMESTAMPTZ NULL
+);
+
+`, string(ddl))
+	})
+
+	t.Run("MySQL", func(t *testing.T) {
+		ddl, err := GenerateSQL(output, SQLOptions{Dialect: MySQL})
+		require.NoError(t, err)
+		require.Contains(t, string(ddl), "CREATE TABLE `simple_struct` (\n  `id` BIGINT NOT NULL,\n  `name` VARCHAR(255) NOT NULL,")
+		require.Contains(t, string(ddl), "`date_updated` DATETIME NULL")
+	})
+
+	t.Run("TagOptions", func(t *testing.T) {
+		code := `
+		package models
+
+		type Timestamps struct {
+			CreatedAt time.Time
+			UpdatedAt *time.Time
+		}
+
+		type OrgID int64
+
+		type Account struct {
+			ID     int64   ` + "`db:\"id,pk\"`" + `
+			Email  string  ` + "`db:\"email,unique,size=320\"`" + `
+			Org    *OrgID  ` + "`db:\"org_id,index\"`" + `
+			Status string  ` + "`db:\"status,default='active'\"`" + `
+			Notes  string
+			Timestamps
+		}
+
+		// Admin is a model embedding another model
+		type Admin struct {
+			Account
+			Level int ` + "`db:\"level\"`" + `
+		}
+
+		type Membership struct {
+			AccountID int64 ` + "`db:\"account_id,pk\"`" + `
+			OrgID     int64 ` + "`db:\"org_id,pk\"`" + `
+		}
+
+		type Event struct {
+			ID      int64       ` + "`db:\"id,pk\"`" + `
+			Payload interface{} ` + "`db:\"payload\"`" + `
+			Code    string      ` + "`db:\"code,size=0\"`" + `
+		}
+		`
+		output, err := ParseString(code)
+		require.NoError(t, err)
+
+		ddl, err := GenerateSQL(output, SQLOptions{Dialect: SQLite})
+		require.NoError(t, err)
+		require.Contains(t, string(ddl), `CREATE TABLE "account" (
+  "id" INTEGER NOT NULL PRIMARY KEY,
+  "email" VARCHAR(320) NOT NULL UNIQUE,
+  "org_id" INTEGER NULL,
+  "status" TEXT NOT NULL DEFAULT 'active',
+  "created_at" DATETIME NOT NULL,
+  "updated_at" DATETIME NULL
+);
+CREATE INDEX "idx_account_org_id" ON "account" ("org_id");
+`)
+		require.Contains(t, string(ddl), `-- Admin is a model embedding another model
+CREATE TABLE "admin" (
+  "id" INTEGER NOT NULL PRIMARY KEY,`)
+		require.Contains(t, string(ddl), `  "updated_at" DATETIME NULL,
+  "level" INTEGER NOT NULL
+);`)
+		require.Contains(t, string(ddl), `  PRIMARY KEY ("account_id", "org_id")`)
+		require.NotContains(t, string(ddl), `CREATE TABLE "timestamps"`)
+
+		// Fields without a column type are left out instead of failing every table
+		require.Contains(t, string(ddl), `-- skipped Event.Payload: no column type for interface{}
+-- skipped Event.Code: invalid size "0"
+CREATE TABLE "event" (
+  "id" INTEGER NOT NULL PRIMARY KEY
+);
+`)
+	})
+
+	t.Run("UnknownDialect", func(t *testing.T) {
+		_, err := GenerateSQL(output, SQLOptions{Dialect: "oracle"})
+		require.Error(t, err)
+	})
+
+	t.Run("Packages", func(t *testing.T) {
+		output, err := ParseSources(map[string]string{
+			"models/models.go": `package models
+
+type Base struct {
+	ID int64 ` + "`db:\"id,pk\"`" + `
+}
+`,
+			"audit/audit.go": `package audit
+
+import "example.com/models"
+
+// Base of audit is a mixin, unlike models.Base
+type Base struct {
+	Actor string
+}
+
+type Entry struct {
+	models.Base
+	Base
+	Note string ` + "`db:\"note\"`" + `
+}
+`,
+		})
+		require.NoError(t, err)
+		for i := range output.Packages {
+			if output.Packages[i].Package == "models" {
+				output.Packages[i].Path = "example.com/models"
+			}
+		}
+
+		ddl, err := GenerateSQL(output, SQLOptions{})
+		require.NoError(t, err)
+		require.Contains(t, string(ddl), `CREATE TABLE "entry" (
+  "id" BIGINT NOT NULL PRIMARY KEY,
+  "actor" TEXT NOT NULL,
+  "note" TEXT NOT NULL
+);`)
+		// The table of models.Base is kept although audit embeds a Base mixin
+		require.Contains(t, string(ddl), `CREATE TABLE "base" (
+  "id" BIGINT NOT NULL PRIMARY KEY
+);`)
+	})
+}
@@ -9,82 +9,157 @@ import (
 	"go/format"
 	"go/parser"
 	"go/token"
+	"go/types"
 	"io/fs"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 )
 
+// SchemaVersion is the version of the output format described by schema/output.schema.json.
+// It changes whenever a field is renamed, removed or changes meaning; adding fields keeps it.
+const SchemaVersion = "1"
+
+// Output is the result of parsing one or more packages.
 type Output struct {
-	Packages []Package `json:"packages"`
+	SchemaVersion string       `json:"schemaVersion"`
+	Packages      []Package    `json:"packages"`
+	Diagnostics   []Diagnostic `json:"diagnostics,omitempty"` // Problems that left packages out, see ParseContext
+}
+
+// Diagnostic is a file or directory that could not be parsed.
+type Diagnostic struct {
+	Message string `json:"message"`
+	Position
+}
+
+// Position is where a declaration starts in the source.
+type Position struct {
+	File string `json:"file,omitempty"` // File name as given to the parser
+	Line int    `json:"line,omitempty"` // 1-based line number
 }
 
+// Package is a parsed Go package.
 type Package struct {
 	Package    string      `json:"package"`
-	Imports    []string    `json:"imports,omitemity"`
-	Structs    []Struct    `json:"structs,omitemity"`
-	Functions  []Function  `json:"functions,omitemity"`
-	Variables  []Variable  `json:"variables,omitemity"`
-	Constants  []Constant  `json:"constants,omitemity"`
-	Interfaces []Interface `json:"interfaces,omitemity"`
+	Path       string      `json:"path,omitempty"` // Import path, when the package lives inside a Go module
+	Imports    []string    `json:"imports,omitempty"`
+	Structs    []Struct    `json:"structs,omitempty"`
+	Functions  []Function  `json:"functions,omitempty"`
+	Variables  []Variable  `json:"variables,omitempty"`
+	Constants  []Constant  `json:"constants,omitempty"`
+	Interfaces []Interface `json:"interfaces,omitempty"`
+	Enums      []Enum      `json:"enums,omitempty"`
+	Tests      []Test      `json:"tests,omitempty"` // Only when test files are included
+	Types      []Type      `json:"types,omitempty"` // Every named type, with its method sets
 }
 
+// Interface is an interface type declaration.
 type Interface struct {
-	Name    string   `json:"name"`
-	Methods []Method `json:"methods,omitemity"`
-	Docs    []string `json:"docs,omitemity"`
+	Name       string   `json:"name"`
+	Methods    []Method `json:"methods,omitempty"`
+	Embeds     []string `json:"embeds,omitempty"` // Embedded interfaces (e.g., "io.Reader")
+	Docs       []string `json:"docs,omitempty"`
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
 }
 
+// Struct is a struct type declaration together with its methods.
 type Struct struct {
-	Name    string   `json:"name"`
-	Fields  []Field  `json:"fields,omitemity"`
-	Methods []Method `json:"methods,omitemity"`
-	Docs    []string `json:"docs,omitemity"`
+	Name       string   `json:"name"`
+	Fields     []Field  `json:"fields,omitempty"`
+	Methods    []Method `json:"methods,omitempty"`
+	Docs       []string `json:"docs,omitempty"`
+	Size       int64    `json:"size,omitempty"`       // Bytes, set by ComputeLayout
+	Align      int64    `json:"align,omitempty"`      // Bytes, set by ComputeLayout
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file, including GOOS/GOARCH file name suffixes (e.g., "linux && amd64")
+	Position
 }
 
+// Method is a method declared on a type or listed in an interface.
 type Method struct {
-	Receiver  string   `json:"receiver,omitempty"` // Receiver type (e.g., "*MyStruct" or "MyStruct")
-	Name      string   `json:"name"`
-	Params    []Param  `json:"params,omitemity"`
-	Returns   []Param  `json:"returns,omitemity"`
-	Docs      []string `json:"docs,omitemity"`
-	Signature string   `json:"signature"`
-	Body      string   `json:"body,omitempty"` // New field for method body
+	Receiver   string   `json:"receiver,omitempty"` // Receiver type (e.g., "*MyStruct" or "MyStruct")
+	Name       string   `json:"name"`
+	Params     []Param  `json:"params,omitempty"`
+	Returns    []Param  `json:"returns,omitempty"`
+	Docs       []string `json:"docs,omitempty"`
+	Signature  string   `json:"signature"`            // Declaration without the body
+	Body       string   `json:"body,omitempty"`       // Source of the method body
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
 }
 
+// Function is a package level function.
 type Function struct {
-	Name      string   `json:"name"`
-	Params    []Param  `json:"params,omitemity"`
-	Returns   []Param  `json:"returns,omitemity"`
-	Docs      []string `json:"docs,omitemity"`
-	Signature string   `json:"signature"`
-	Body      string   `json:"body,omitempty"` // New field for function body
+	Name       string   `json:"name"`
+	Params     []Param  `json:"params,omitempty"`
+	Returns    []Param  `json:"returns,omitempty"`
+	Docs       []string `json:"docs,omitempty"`
+	Signature  string   `json:"signature"`
+	Body       string   `json:"body,omitempty"`       // Source of the function body
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
 }
+
+// Param is a parameter or result of a function or method.
 type Param struct {
 	Name string `json:"name"` // Name of the parameter or return value
 	Type string `json:"type"` // Type (e.g., "int", "*string")
 }
 
+// Field is a struct field.
 type Field struct {
-	Name    string   `json:"name"`
-	Type    string   `json:"type"`
-	Tag     string   `json:"tag"`
-	Private bool     `json:"private"`
-	Pointer bool     `json:"pointer"`
-	Slice   bool     `json:"slice"`
-	Docs    []string `json:"docs,omitemity"`
-	Comment string   `json:"comment,omitempty"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Tag        string   `json:"tag"`     // Raw struct tag, without backquotes
+	Private    bool     `json:"private"` // Whether the field is unexported
+	Pointer    bool     `json:"pointer"`
+	Slice      bool     `json:"slice"`
+	Docs       []string `json:"docs,omitempty"`
+	Comment    string   `json:"comment,omitempty"`    // Trailing line comment
+	Offset     int64    `json:"offset,omitempty"`     // Bytes from the start of the struct, set by ComputeLayout
+	Size       int64    `json:"size,omitempty"`       // Bytes, set by ComputeLayout
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
 }
 
+// Variable is a package level variable.
 type Variable struct {
-	Name string   `json:"name"`
-	Type string   `json:"type"`
-	Docs []string `json:"docs,omitemity"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type"`
+	Docs       []string `json:"docs,omitempty"`
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
 }
 
+// Constant is a package level constant.
 type Constant struct {
-	Name  string   `json:"name"`
-	Value string   `json:"value"`
-	Docs  []string `json:"docs,omitemity"`
+	Name       string   `json:"name"`
+	Type       string   `json:"type,omitempty"` // Declared type, inherited from the previous spec in a const block
+	Value      string   `json:"value"`          // Value expression as written in the source
+	Docs       []string `json:"docs,omitempty"`
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
+}
+
+// Enum is a named string or integer type together with the constants declared with it.
+type Enum struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"` // Underlying type (e.g., "string", "int")
+	Values     []EnumValue `json:"values,omitempty"`
+	Docs       []string    `json:"docs,omitempty"`
+	Constraint string      `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
+}
+
+// EnumValue is a constant belonging to an Enum.
+type EnumValue struct {
+	Name       string   `json:"name"`
+	Value      string   `json:"value"` // Evaluated value as a Go literal (e.g., "\"red\"", "2")
+	Docs       []string `json:"docs,omitempty"`
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
 }
 
 func ParseFile(fileOrDirectory string) (*Output, error) {
@@ -109,407 +184,450 @@ func ParseString(fileContent string) (*Output, error) {
 		},
 	}
 
-	return extractStructsFromPackages(packages)
+	return extractStructsFromPackages(fset, packages)
 }
 
 func ParseDirectoryWithFilter(fileOrDirectory string, filter func(fs.FileInfo) bool) (*Output, error) {
-	fi, err := os.Stat(fileOrDirectory)
+	return ParseOptions{Filter: filter}.ParseDirectory(fileOrDirectory)
+}
+
+// importPathForDir derives the import path of a directory from the nearest go.mod above
+// it. It returns "" when the directory is not inside a module.
+func importPathForDir(dir string) string {
+	abs, err := filepath.Abs(dir)
 	if err != nil {
-		return nil, err
+		return ""
 	}
-
-	var packages map[string]*ast.Package
-	fset := token.NewFileSet()
-
-	switch mode := fi.Mode(); {
-	case mode.IsDir():
-		packages, err = parser.ParseDir(fset, fileOrDirectory, filter, parser.ParseComments|parser.AllErrors|parser.DeclarationErrors)
-		if err != nil {
-			return nil, err
+	for current := abs; ; current = filepath.Dir(current) {
+		content, err := os.ReadFile(filepath.Join(current, "go.mod"))
+		if err == nil {
+			module := modulePath(content)
+			if module == "" {
+				return ""
+			}
+			rel, err := filepath.Rel(current, abs)
+			if err != nil {
+				return ""
+			}
+			if rel == "." {
+				return module
+			}
+			return module + "/" + filepath.ToSlash(rel)
 		}
-	case mode.IsRegular():
-		file, err := parser.ParseFile(fset, fileOrDirectory, nil, parser.ParseComments|parser.AllErrors|parser.DeclarationErrors)
-		if err != nil {
-			return nil, err
+		if filepath.Dir(current) == current {
+			return ""
 		}
-		packages = map[string]*ast.Package{
-			fileOrDirectory: {
-				Name:  file.Name.Name,
-				Files: map[string]*ast.File{fileOrDirectory: file},
-			},
+	}
+}
+
+// modulePath returns the module path declared in go.mod content.
+func modulePath(gomod []byte) string {
+	for _, line := range strings.Split(string(gomod), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
 		}
 	}
+	return ""
+}
 
-	return extractStructsFromPackages(packages)
+func extractStructsFromPackages(fset *token.FileSet, packages map[string]*ast.Package) (*Output, error) {
+	files := make(map[string][]fileDecls, len(packages))
+	for _, pkg := range sortedPackages(packages) {
+		for _, file := range sortedFiles(pkg) {
+			decls, err := extractFile(fset, file)
+			if err != nil {
+				return nil, err
+			}
+			files[pkg.Name] = append(files[pkg.Name], decls)
+		}
+	}
+	return linkPackages(files), nil
 }
 
-func extractStructsFromPackages(packages map[string]*ast.Package) (*Output, error) {
-	output := &Output{
-		Packages: make([]Package, 0, len(packages)),
+// extractFile extracts the declarations of a file. It only looks at the file itself, so
+// that the result can be cached by file content; linkPackages puts the files of a package
+// together.
+func extractFile(fset *token.FileSet, file *ast.File) (fileDecls, error) {
+	decls := fileDecls{
+		File:    fset.Position(file.Package).Filename,
+		Package: file.Name.Name,
 	}
 
-	for _, pkg := range packages {
-		outPkg := Package{
-			Structs:   make([]Struct, 0),
-			Functions: make([]Function, 0),
-			Variables: make([]Variable, 0),
-			Constants: make([]Constant, 0),
-			Imports:   make([]string, 0),
-		}
+	for _, importSpec := range file.Imports {
+		decls.Imports = append(decls.Imports, strings.Trim(importSpec.Path.Value, "\""))
+	}
 
-		docPkg := doc.New(pkg, "", doc.AllDecls|doc.AllMethods|doc.PreserveAST)
-		outPkg.Package = pkg.Name // Set package name
+	testFile := strings.HasSuffix(decls.File, "_test.go")
+	examples := map[string]*doc.Example{}
+	if testFile {
+		for _, example := range doc.Examples(file) {
+			examples["Example"+example.Name] = example
+		}
+	}
 
-		// Extract structs and other types
-		for _, t := range docPkg.Types {
-			if t == nil || t.Decl == nil {
-				return nil, errors.New("t or t.Decl is nil")
+	for _, decl := range file.Decls {
+		switch decl := decl.(type) {
+		case *ast.FuncDecl:
+			fn, err := extractFunc(fset, decl)
+			if err != nil {
+				return fileDecls{}, err
 			}
-
-			// Extract structs
-			for _, spec := range t.Decl.Specs {
-				typeSpec, ok := spec.(*ast.TypeSpec)
-				if !ok {
-					return nil, errors.New("not a *ast.TypeSpec")
+			if decl.Recv != nil {
+				// Like go/doc, methods of types from other packages are left out
+				if len(decl.Recv.List) == 0 {
+					continue
 				}
-
-				structType, ok := typeSpec.Type.(*ast.StructType)
-				if ok {
-					parsedStruct := Struct{
-						Name:    t.Name,
-						Fields:  make([]Field, 0, len(structType.Fields.List)),
-						Docs:    getDocsForStruct(t.Doc),
-						Methods: make([]Method, 0),
+				name, imported := baseTypeName(decl.Recv.List[0].Type)
+				if imported || name == "" || name == "_" {
+					continue
+				}
+				fn.RecvType = name
+				decls.Methods = append(decls.Methods, fn)
+				continue
+			}
+			if kind, ok := testKind(decl); ok && testFile {
+				test := Test{Name: fn.Name, Kind: kind, Position: fn.Position}
+				if len(fn.Docs) > 1 || len(fn.Docs) == 1 && fn.Docs[0] != "" {
+					test.Docs = fn.Docs
+				}
+				if example, ok := examples[fn.Name]; ok {
+					test.Output = example.Output
+					test.Unordered = example.Unordered
+				}
+				fn.Test = &test
+			}
+			decls.Functions = append(decls.Functions, fn)
+
+		case *ast.GenDecl:
+			switch decl.Tok {
+			case token.TYPE:
+				for _, spec := range decl.Specs {
+					typeSpec, ok := spec.(*ast.TypeSpec)
+					if !ok {
+						return fileDecls{}, errors.New("not a *ast.TypeSpec")
 					}
+					if typeSpec.Name.Name == "_" {
+						continue
+					}
+					typeDecl, err := extractType(fset, decl, typeSpec)
+					if err != nil {
+						return fileDecls{}, err
+					}
+					decls.Types = append(decls.Types, typeDecl)
+				}
 
-					for _, fvalue := range structType.Fields.List {
-						name := ""
-						if len(fvalue.Names) > 0 {
-							name = fvalue.Names[0].Obj.Name
-						}
-
-						field := Field{
-							Name:    name,
-							Type:    "",
-							Tag:     "",
-							Pointer: false,
-							Slice:   false,
-						}
-
-						if len(field.Name) > 0 {
-							field.Private = strings.ToLower(string(field.Name[0])) == string(field.Name[0])
-						}
-
-						if fvalue.Doc != nil {
-							field.Docs = getDocsForFieldAst(fvalue.Doc)
+			case token.CONST:
+				// Extract constants
+				var (
+					lastType   ast.Expr
+					lastValues []ast.Expr
+				)
+				for idx, spec := range decl.Specs {
+					valSpec, ok := spec.(*ast.ValueSpec)
+					if !ok {
+						continue
+					}
+					// A spec without values repeats the type and values of the previous one
+					if valSpec.Type != nil || len(valSpec.Values) > 0 {
+						lastType, lastValues = valSpec.Type, valSpec.Values
+					}
+					for i, name := range valSpec.Names {
+						parsedConstant := constDecl{
+							Constant: Constant{
+								Position: position(fset, name.Pos()),
+								Name:     name.Name,
+								Value:    "",
+								Docs:     getDocsForFieldAst(valSpec.Doc),
+							},
+							Iota: idx,
 						}
-
-						if fvalue.Comment != nil {
-							field.Comment = cleanDocText(fvalue.Comment.Text())
+						if lastType != nil {
+							parsedConstant.Type, _, _, _ = getType(lastType)
 						}
-
-						if fvalue.Tag != nil {
-							field.Tag = strings.Trim(fvalue.Tag.Value, "`")
+						if i < len(valSpec.Values) {
+							parsedConstant.Value = exprToString(valSpec.Values[i])
 						}
-
-						var err error
-						field.Type, field.Slice, field.Pointer, err = getType(fvalue.Type)
-						if err != nil {
-							return nil, err
+						if i < len(lastValues) {
+							parsedConstant.Expr = types.ExprString(lastValues[i])
 						}
-
-						parsedStruct.Fields = append(parsedStruct.Fields, field)
+						decls.Constants = append(decls.Constants, parsedConstant)
 					}
-
-					outPkg.Structs = append(outPkg.Structs, parsedStruct)
 				}
-				// Extract interfaces
-				if interfaceType, ok := typeSpec.Type.(*ast.InterfaceType); ok {
-					parsedInterface := Interface{
-						Name:    t.Name,
-						Methods: make([]Method, 0),
-						Docs:    getDocsForStruct(t.Doc),
-					}
 
-					for _, m := range interfaceType.Methods.List {
-						if funcType, ok := m.Type.(*ast.FuncType); ok {
-							method := Method{
-								Name:    m.Names[0].Name,
-								Params:  extractParams(funcType.Params),
-								Returns: extractParams(funcType.Results),
-								Docs:    getDocsForFieldAst(m.Doc),
-								Signature: fmt.Sprintf("%s(%s) (%s)", m.Names[0].Name,
-									formatParams(funcType.Params), formatParams(funcType.Results)),
-							}
-							parsedInterface.Methods = append(parsedInterface.Methods, method)
+			case token.VAR:
+				// Extract variables
+				for _, spec := range decl.Specs {
+					valSpec, ok := spec.(*ast.ValueSpec)
+					if !ok {
+						continue
+					}
+					for _, name := range valSpec.Names {
+						varType := ""
+						if valSpec.Type != nil {
+							varType, _, _, _ = getType(valSpec.Type)
 						}
+						variable := Variable{
+							Position: position(fset, name.Pos()),
+							Name:     name.Name,
+							Type:     varType,
+							Docs:     getDocsForFieldAst(valSpec.Doc),
+						}
+						decls.Variables = append(decls.Variables, variable)
 					}
-
-					outPkg.Interfaces = append(outPkg.Interfaces, parsedInterface)
 				}
 			}
+		}
+	}
+	return decls, nil
+}
 
-			// Extract methods associated with the struct
-			// Extract methods associated with the struct
-			for _, spec := range t.Methods {
-				funcDecl := spec.Decl
-				receiver, _, _, _ := getType(funcDecl.Recv.List[0].Type)
-
-				method := Method{
-					Name:     funcDecl.Name.Name,
-					Receiver: receiver,
-					Docs:     getDocsForField([]string{spec.Doc}),
-				}
-
-				// Parse function parameters
-				params := []Param{}
-				for _, param := range funcDecl.Type.Params.List {
-					paramType, _, _, err := getType(param.Type)
-					if err != nil {
-						return nil, err
-					}
-
-					for _, name := range param.Names {
-						params = append(params, Param{
-							Name: name.Name,
-							Type: paramType,
-						})
-					}
-				}
-				method.Params = params
-
-				// Parse return types
-				returns := []Param{}
-				if funcDecl.Type.Results != nil {
-					for _, result := range funcDecl.Type.Results.List {
-						returnType, _, _, err := getType(result.Type)
-						if err != nil {
-							return nil, err
-						}
+// extractType extracts a type declaration, and the struct or interface it declares.
+func extractType(fset *token.FileSet, decl *ast.GenDecl, typeSpec *ast.TypeSpec) (typeDecl, error) {
+	// As in go/doc, a type without documentation of its own uses that of its declaration
+	docGroup := typeSpec.Doc
+	if docGroup == nil {
+		docGroup = decl.Doc
+	}
+	typeName := typeSpec.Name.Name
+	parsed := typeDecl{
+		Type: namedType(typeName, typeSpec, position(fset, typeSpec.Pos())),
+		Docs: getDocsForStruct(docGroup.Text()),
+	}
 
-						if len(result.Names) > 0 {
-							for _, name := range result.Names {
-								returns = append(returns, Param{
-									Name: name.Name,
-									Type: returnType,
-								})
-							}
-						} else {
-							returns = append(returns, Param{
-								Name: "",
-								Type: returnType,
-							})
-						}
-					}
-				}
-				method.Returns = returns
+	structType, ok := typeSpec.Type.(*ast.StructType)
+	if ok {
+		parsedStruct := Struct{
+			Position: position(fset, typeSpec.Pos()),
+			Name:     typeName,
+			Fields:   make([]Field, 0, len(structType.Fields.List)),
+			Docs:     parsed.Docs,
+			Methods:  make([]Method, 0),
+		}
 
-				// Extract the function body as a string
-				var bodyBuf bytes.Buffer
-				if funcDecl.Body != nil {
-					err := format.Node(&bodyBuf, token.NewFileSet(), funcDecl.Body)
-					if err != nil {
-						return nil, err
-					}
-					method.Body = bodyBuf.String()
-				}
+		for _, fvalue := range structType.Fields.List {
+			name := ""
+			if len(fvalue.Names) > 0 {
+				name = fvalue.Names[0].Obj.Name
+			} else if embedded, imported := baseTypeName(fvalue.Type); !imported && embedded != "" && embedded != "_" {
+				_, pointer := fvalue.Type.(*ast.StarExpr)
+				parsed.Embedded = append(parsed.Embedded, embeddedDecl{Name: embedded, Pointer: pointer})
+			}
 
-				// Construct the full method signature for easy comparison
-				paramStrings := []string{}
-				for _, param := range method.Params {
-					if param.Name != "" {
-						paramStrings = append(paramStrings, param.Name+" "+param.Type)
-					} else {
-						paramStrings = append(paramStrings, param.Type)
-					}
-				}
+			field := Field{
+				Position: position(fset, fvalue.Pos()),
+				Name:     name,
+				Type:     "",
+				Tag:      "",
+				Pointer:  false,
+				Slice:    false,
+			}
 
-				returnStrings := []string{}
-				for _, ret := range method.Returns {
-					if ret.Name != "" {
-						returnStrings = append(returnStrings, ret.Name+" "+ret.Type)
-					} else {
-						returnStrings = append(returnStrings, ret.Type)
-					}
-				}
+			if len(field.Name) > 0 {
+				field.Private = strings.ToLower(string(field.Name[0])) == string(field.Name[0])
+			}
 
-				method.Signature = fmt.Sprintf("%s(%s) (%s)",
-					method.Name,
-					strings.Join(paramStrings, ", "),
-					strings.Join(returnStrings, ", "),
-				)
+			if fvalue.Doc != nil {
+				field.Docs = getDocsForFieldAst(fvalue.Doc)
+			}
 
-				// Find the struct and add the method
-				for k, v := range outPkg.Structs {
-					if strings.Trim(method.Receiver, "*") == v.Name {
-						outPkg.Structs[k].Methods = append(outPkg.Structs[k].Methods, method)
-					}
-				}
+			if fvalue.Comment != nil {
+				field.Comment = cleanDocText(fvalue.Comment.Text())
 			}
-		}
 
-		// Extract functions
-		for _, t := range docPkg.Funcs {
-			if t == nil || t.Decl == nil {
-				return nil, errors.New("t or t.Decl is nil")
+			if fvalue.Tag != nil {
+				field.Tag = strings.Trim(fvalue.Tag.Value, "`")
 			}
 
-			funcDecl := t.Decl
-			function := Function{
-				Name: t.Name,
-				Docs: getDocsForField([]string{t.Doc}),
+			var err error
+			field.Type, field.Slice, field.Pointer, err = getType(fvalue.Type)
+			if err != nil {
+				return typeDecl{}, err
 			}
 
-			// Parse function parameters
-			params := []Param{}
-			for _, param := range funcDecl.Type.Params.List {
-				paramType, _, _, err := getType(param.Type)
-				if err != nil {
-					return nil, err
-				}
+			parsedStruct.Fields = append(parsedStruct.Fields, field)
 
-				for _, name := range param.Names {
-					params = append(params, Param{
-						Name: name.Name,
-						Type: paramType,
-					})
-				}
+			// Fields declared together, as in "X, Y int", differ only by name
+			for k := 1; k < len(fvalue.Names); k++ {
+				another := field
+				another.Name = fvalue.Names[k].Name
+				another.Private = !ast.IsExported(another.Name)
+				parsedStruct.Fields = append(parsedStruct.Fields, another)
 			}
-			function.Params = params
+		}
 
-			// Parse return types
-			returns := []Param{}
-			if funcDecl.Type.Results != nil {
-				for _, result := range funcDecl.Type.Results.List {
-					returnType, _, _, err := getType(result.Type)
-					if err != nil {
-						return nil, err
-					}
+		parsed.Struct = &parsedStruct
+	}
+	// Extract interfaces
+	if interfaceType, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+		parsedInterface := Interface{
+			Position: position(fset, typeSpec.Pos()),
+			Name:     typeName,
+			Methods:  make([]Method, 0),
+			Docs:     parsed.Docs,
+		}
 
-					if len(result.Names) > 0 {
-						for _, name := range result.Names {
-							returns = append(returns, Param{
-								Name: name.Name,
-								Type: returnType,
-							})
-						}
-					} else {
-						returns = append(returns, Param{
-							Name: "",
-							Type: returnType,
-						})
-					}
+		for _, m := range interfaceType.Methods.List {
+			if len(m.Names) == 0 {
+				// Type unions of constraints are not interfaces to embed
+				if embed, _, _, err := getType(m.Type); err == nil {
+					parsedInterface.Embeds = append(parsedInterface.Embeds, embed)
 				}
+				continue
 			}
-			function.Returns = returns
-
-			// Extract the function body as a string
-			var bodyBuf bytes.Buffer
-			if funcDecl.Body != nil {
-				err := format.Node(&bodyBuf, token.NewFileSet(), funcDecl.Body)
-				if err != nil {
-					return nil, err
+			if funcType, ok := m.Type.(*ast.FuncType); ok {
+				method := Method{
+					Position: position(fset, m.Pos()),
+					Name:     m.Names[0].Name,
+					Params:   extractParams(funcType.Params),
+					Returns:  extractParams(funcType.Results),
+					Docs:     getDocsForFieldAst(m.Doc),
+					Signature: fmt.Sprintf("%s(%s) (%s)", m.Names[0].Name,
+						formatParams(funcType.Params), formatParams(funcType.Results)),
 				}
-				function.Body = bodyBuf.String()
+				parsedInterface.Methods = append(parsedInterface.Methods, method)
 			}
+		}
 
-			// Construct the full function signature for easy comparison
-			paramStrings := []string{}
-			for _, param := range function.Params {
-				if param.Name != "" {
-					paramStrings = append(paramStrings, param.Name+" "+param.Type)
-				} else {
-					paramStrings = append(paramStrings, param.Type)
-				}
-			}
+		parsed.Interface = &parsedInterface
+	}
+	return parsed, nil
+}
 
-			returnStrings := []string{}
-			for _, ret := range function.Returns {
-				if ret.Name != "" {
-					returnStrings = append(returnStrings, ret.Name+" "+ret.Type)
-				} else {
-					returnStrings = append(returnStrings, ret.Type)
-				}
-			}
+// extractFunc extracts a function or method declaration, including its body.
+func extractFunc(fset *token.FileSet, fn *ast.FuncDecl) (funcDecl, error) {
+	method := Method{
+		Position: position(fset, fn.Pos()),
+		Name:     fn.Name.Name,
+		Docs:     getDocsForField([]string{fn.Doc.Text()}),
+	}
+	if fn.Recv != nil && len(fn.Recv.List) > 0 {
+		method.Receiver, _, _, _ = getType(fn.Recv.List[0].Type)
+	}
 
-			function.Signature = fmt.Sprintf("%s(%s) (%s)",
-				function.Name,
-				strings.Join(paramStrings, ", "),
-				strings.Join(returnStrings, ", "),
-			)
+	// Parse function parameters
+	params := []Param{}
+	for _, param := range fn.Type.Params.List {
+		paramType, _, _, err := getType(param.Type)
+		if err != nil {
+			return funcDecl{}, err
+		}
 
-			outPkg.Functions = append(outPkg.Functions, function)
+		for _, name := range param.Names {
+			params = append(params, Param{
+				Name: name.Name,
+				Type: paramType,
+			})
+		}
+		if len(param.Names) == 0 {
+			params = append(params, Param{Type: paramType})
 		}
+	}
+	method.Params = params
+
+	// Parse return types
+	returns := []Param{}
+	if fn.Type.Results != nil {
+		for _, result := range fn.Type.Results.List {
+			returnType, _, _, err := getType(result.Type)
+			if err != nil {
+				return funcDecl{}, err
+			}
 
-		// Extract imports
-		for _, file := range pkg.Files {
-			for _, importSpec := range file.Imports {
-				importPath := strings.Trim(importSpec.Path.Value, "\"")
-				outPkg.Imports = append(outPkg.Imports, importPath)
+			if len(result.Names) > 0 {
+				for _, name := range result.Names {
+					returns = append(returns, Param{
+						Name: name.Name,
+						Type: returnType,
+					})
+				}
+			} else {
+				returns = append(returns, Param{
+					Name: "",
+					Type: returnType,
+				})
 			}
 		}
-		// unique imports
-		uniqueImports := make(map[string]struct{})
-		for _, v := range outPkg.Imports {
-			uniqueImports[v] = struct{}{}
+	}
+	method.Returns = returns
+
+	// Extract the function body as a string
+	var bodyBuf bytes.Buffer
+	if fn.Body != nil {
+		err := format.Node(&bodyBuf, token.NewFileSet(), fn.Body)
+		if err != nil {
+			return funcDecl{}, err
+		}
+		method.Body = bodyBuf.String()
+	}
+
+	// Construct the full signature for easy comparison
+	paramStrings := []string{}
+	for _, param := range method.Params {
+		if param.Name != "" {
+			paramStrings = append(paramStrings, param.Name+" "+param.Type)
+		} else {
+			paramStrings = append(paramStrings, param.Type)
 		}
-		outPkg.Imports = make([]string, 0, len(uniqueImports))
-		for k := range uniqueImports {
-			outPkg.Imports = append(outPkg.Imports, k)
+	}
+
+	returnStrings := []string{}
+	for _, ret := range method.Returns {
+		if ret.Name != "" {
+			returnStrings = append(returnStrings, ret.Name+" "+ret.Type)
+		} else {
+			returnStrings = append(returnStrings, ret.Type)
 		}
+	}
 
-		// Extract constants and variables
-		for _, file := range pkg.Files {
-			for _, decl := range file.Decls {
-				switch decl := decl.(type) {
-				case *ast.GenDecl:
-					if decl.Tok == token.CONST {
-						// Extract constants
-						for _, spec := range decl.Specs {
-							valSpec, ok := spec.(*ast.ValueSpec)
-							if !ok {
-								continue
-							}
-							for i, name := range valSpec.Names {
-								constant := Constant{
-									Name:  name.Name,
-									Value: "",
-									Docs:  getDocsForFieldAst(valSpec.Doc),
-								}
-								if i < len(valSpec.Values) {
-									constant.Value = exprToString(valSpec.Values[i])
-								}
-								outPkg.Constants = append(outPkg.Constants, constant)
-							}
-						}
-					} else if decl.Tok == token.VAR {
-						// Extract variables
-						for _, spec := range decl.Specs {
-							valSpec, ok := spec.(*ast.ValueSpec)
-							if !ok {
-								continue
-							}
-							for _, name := range valSpec.Names {
-								varType := ""
-								if valSpec.Type != nil {
-									varType, _, _, _ = getType(valSpec.Type)
-								}
-								variable := Variable{
-									Name: name.Name,
-									Type: varType,
-									Docs: getDocsForFieldAst(valSpec.Doc),
-								}
-								outPkg.Variables = append(outPkg.Variables, variable)
-							}
-						}
-					}
-				}
-			}
+	method.Signature = fmt.Sprintf("%s(%s) (%s)",
+		method.Name,
+		strings.Join(paramStrings, ", "),
+		strings.Join(returnStrings, ", "),
+	)
+
+	return funcDecl{Method: method, Documented: fn.Doc.Text() != ""}, nil
+}
+
+// position returns the file and line of pos.
+func position(fset *token.FileSet, pos token.Pos) Position {
+	p := fset.Position(pos)
+	return Position{File: p.Filename, Line: p.Line}
+}
+
+// sortedPackages returns the packages ordered by name, then by key, so output does not
+// depend on map iteration order.
+func sortedPackages(packages map[string]*ast.Package) []*ast.Package {
+	keys := make([]string, 0, len(packages))
+	for key := range packages {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := packages[keys[i]], packages[keys[j]]
+		if a.Name != b.Name {
+			return a.Name < b.Name
 		}
-		output.Packages = append(output.Packages, outPkg)
+		return keys[i] < keys[j]
+	})
+	sorted := make([]*ast.Package, len(keys))
+	for i, key := range keys {
+		sorted[i] = packages[key]
 	}
+	return sorted
+}
 
-	return output, nil
+// sortedFiles returns the files of pkg ordered by file name.
+func sortedFiles(pkg *ast.Package) []*ast.File {
+	names := make([]string, 0, len(pkg.Files))
+	for name := range pkg.Files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	files := make([]*ast.File, len(names))
+	for i, name := range names {
+		files[i] = pkg.Files[name]
+	}
+	return files
 }
 
 func extractParams(fieldList *ast.FieldList) []Param {
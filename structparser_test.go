@@ -10,277 +10,277 @@ func TestParseDirectory(t *testing.T) {
 	tmp, err := ParseDirectory("./example")
 	require.NoError(t, err)
 
-	parsed := newHelper(&tmp.Packages[0])
+	parsed := NewIndex(tmp)
 
 	t.Run("FirstStruct", func(t *testing.T) {
-		firstStruct := parsed.Struct("FirstStruct")
+		firstStruct := mustStruct(t, parsed, "FirstStruct")
 		require.Len(t, firstStruct.Docs, 2)
 		require.Equal(t, "FirstStruct this is the comment for the first struct.", firstStruct.Docs[0])
 		require.Equal(t, "This is new line.", firstStruct.Docs[1])
 
 		var f Field
 
-		f = firstStruct.Field("Int")
+		f = mustField(t, parsed, firstStruct.Name, "Int")
 		require.Equal(t, "Int", f.Name)
 		require.Equal(t, "int", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 		require.Equal(t, `json:"int" bson:"int"`, f.Tag)
 
-		f = firstStruct.Field("Int8")
+		f = mustField(t, parsed, firstStruct.Name, "Int8")
 		require.Equal(t, "Int8", f.Name)
 		require.Equal(t, "int8", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 		require.Equal(t, `bson:"int8"`, f.Tag)
 
-		f = firstStruct.Field("Int16")
+		f = mustField(t, parsed, firstStruct.Name, "Int16")
 		require.Equal(t, "Int16", f.Name)
 		require.Equal(t, "int16", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Int32")
+		f = mustField(t, parsed, firstStruct.Name, "Int32")
 		require.Equal(t, "Int32", f.Name)
 		require.Equal(t, "int32", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Int64")
+		f = mustField(t, parsed, firstStruct.Name, "Int64")
 		require.Equal(t, "Int64", f.Name)
 		require.Equal(t, "int64", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Uint")
+		f = mustField(t, parsed, firstStruct.Name, "Uint")
 		require.Equal(t, "Uint", f.Name)
 		require.Equal(t, "uint", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Uintptr")
+		f = mustField(t, parsed, firstStruct.Name, "Uintptr")
 		require.Equal(t, "Uintptr", f.Name)
 		require.Equal(t, "uintptr", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Uint8")
+		f = mustField(t, parsed, firstStruct.Name, "Uint8")
 		require.Equal(t, "Uint8", f.Name)
 		require.Equal(t, "uint8", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Uint16")
+		f = mustField(t, parsed, firstStruct.Name, "Uint16")
 		require.Equal(t, "Uint16", f.Name)
 		require.Equal(t, "uint16", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Uint32")
+		f = mustField(t, parsed, firstStruct.Name, "Uint32")
 		require.Equal(t, "Uint32", f.Name)
 		require.Equal(t, "uint32", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Uint64")
+		f = mustField(t, parsed, firstStruct.Name, "Uint64")
 		require.Equal(t, "Uint64", f.Name)
 		require.Equal(t, "uint64", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Float32")
+		f = mustField(t, parsed, firstStruct.Name, "Float32")
 		require.Equal(t, "Float32", f.Name)
 		require.Equal(t, "float32", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Float64")
+		f = mustField(t, parsed, firstStruct.Name, "Float64")
 		require.Equal(t, "Float64", f.Name)
 		require.Equal(t, "float64", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Complex64")
+		f = mustField(t, parsed, firstStruct.Name, "Complex64")
 		require.Equal(t, "Complex64", f.Name)
 		require.Equal(t, "complex64", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Complex128")
+		f = mustField(t, parsed, firstStruct.Name, "Complex128")
 		require.Equal(t, "Complex128", f.Name)
 		require.Equal(t, "complex128", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Byte")
+		f = mustField(t, parsed, firstStruct.Name, "Byte")
 		require.Equal(t, "Byte", f.Name)
 		require.Equal(t, "byte", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Rune")
+		f = mustField(t, parsed, firstStruct.Name, "Rune")
 		require.Equal(t, "Rune", f.Name)
 		require.Equal(t, "rune", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("String")
+		f = mustField(t, parsed, firstStruct.Name, "String")
 		require.Equal(t, "String", f.Name)
 		require.Equal(t, "string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("SecondStruct")
+		f = mustField(t, parsed, firstStruct.Name, "SecondStruct")
 		require.Equal(t, "SecondStruct", f.Name)
 		require.Equal(t, "SecondStruct", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("ArrayInt")
+		f = mustField(t, parsed, firstStruct.Name, "ArrayInt")
 		require.Equal(t, "ArrayInt", f.Name)
 		require.Equal(t, "[3]int", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, true, f.Slice)
 
-		f = firstStruct.Field("SliceString")
+		f = mustField(t, parsed, firstStruct.Name, "SliceString")
 		require.Equal(t, "SliceString", f.Name)
 		require.Equal(t, "[]string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, true, f.Slice)
 
-		f = firstStruct.Field("SlicePointerString")
+		f = mustField(t, parsed, firstStruct.Name, "SlicePointerString")
 		require.Equal(t, "SlicePointerString", f.Name)
 		require.Equal(t, "[]*string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, true, f.Slice)
 
-		f = firstStruct.Field("PointerSliceString")
+		f = mustField(t, parsed, firstStruct.Name, "PointerSliceString")
 		require.Equal(t, "PointerSliceString", f.Name)
 		require.Equal(t, "*[]string", f.Type)
 		require.Equal(t, true, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("PointerSlicePointerString")
+		f = mustField(t, parsed, firstStruct.Name, "PointerSlicePointerString")
 		require.Equal(t, "PointerSlicePointerString", f.Name)
 		require.Equal(t, "*[]*string", f.Type)
 		require.Equal(t, true, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("ChanString")
+		f = mustField(t, parsed, firstStruct.Name, "ChanString")
 		require.Equal(t, "ChanString", f.Name)
 		require.Equal(t, "chan string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("RChanString")
+		f = mustField(t, parsed, firstStruct.Name, "RChanString")
 		require.Equal(t, "RChanString", f.Name)
 		require.Equal(t, "<-chan string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("SChanString")
+		f = mustField(t, parsed, firstStruct.Name, "SChanString")
 		require.Equal(t, "SChanString", f.Name)
 		require.Equal(t, "chan<- string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("MapStringString")
+		f = mustField(t, parsed, firstStruct.Name, "MapStringString")
 		require.Equal(t, "MapStringString", f.Name)
 		require.Equal(t, "map[string]string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("MapPointerStringString")
+		f = mustField(t, parsed, firstStruct.Name, "MapPointerStringString")
 		require.Equal(t, "MapPointerStringString", f.Name)
 		require.Equal(t, "map[*string]string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("MapPointerStringPointerString")
+		f = mustField(t, parsed, firstStruct.Name, "MapPointerStringPointerString")
 		require.Equal(t, "MapPointerStringPointerString", f.Name)
 		require.Equal(t, "map[*string]*string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("PointerMapStringString")
+		f = mustField(t, parsed, firstStruct.Name, "PointerMapStringString")
 		require.Equal(t, "PointerMapStringString", f.Name)
 		require.Equal(t, "*map[string]string", f.Type)
 		require.Equal(t, true, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("PointerMapPointerStringPointerString")
+		f = mustField(t, parsed, firstStruct.Name, "PointerMapPointerStringPointerString")
 		require.Equal(t, "PointerMapPointerStringPointerString", f.Name)
 		require.Equal(t, "*map[*string]*string", f.Type)
 		require.Equal(t, true, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("Func")
+		f = mustField(t, parsed, firstStruct.Name, "Func")
 		require.Equal(t, "Func", f.Name)
 		require.Equal(t, "SomeFunc", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("PointerFunc")
+		f = mustField(t, parsed, firstStruct.Name, "PointerFunc")
 		require.Equal(t, "PointerFunc", f.Name)
 		require.Equal(t, "*SomeFunc", f.Type)
 		require.Equal(t, true, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("MapStringSliceString")
+		f = mustField(t, parsed, firstStruct.Name, "MapStringSliceString")
 		require.Equal(t, "MapStringSliceString", f.Name)
 		require.Equal(t, "map[string][]string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("MapStringSlicePointerString")
+		f = mustField(t, parsed, firstStruct.Name, "MapStringSlicePointerString")
 		require.Equal(t, "MapStringSlicePointerString", f.Name)
 		require.Equal(t, "map[string][]*string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("MapPointerStringSlicePointerString")
+		f = mustField(t, parsed, firstStruct.Name, "MapPointerStringSlicePointerString")
 		require.Equal(t, "MapPointerStringSlicePointerString", f.Name)
 		require.Equal(t, "map[*string][]*string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("MapChanPointerStringStruct")
+		f = mustField(t, parsed, firstStruct.Name, "MapChanPointerStringStruct")
 		require.Equal(t, "MapChanPointerStringStruct", f.Name)
 		require.Equal(t, "map[chan *string]SecondStruct", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("SpecialString")
+		f = mustField(t, parsed, firstStruct.Name, "SpecialString")
 		require.Equal(t, "SpecialString", f.Name)
 		require.Equal(t, "SpecialString", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("PackageStruct")
+		f = mustField(t, parsed, firstStruct.Name, "PackageStruct")
 		require.Equal(t, "PackageStruct", f.Name)
 		require.Equal(t, "other.Struct", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("PointerPackageStruct")
+		f = mustField(t, parsed, firstStruct.Name, "PointerPackageStruct")
 		require.Equal(t, "PointerPackageStruct", f.Name)
 		require.Equal(t, "*other.Struct", f.Type)
 		require.Equal(t, true, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("SlicePointerPackageStruct")
+		f = mustField(t, parsed, firstStruct.Name, "SlicePointerPackageStruct")
 		require.Equal(t, "SlicePointerPackageStruct", f.Name)
 		require.Equal(t, "[]*other.Struct", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, true, f.Slice)
 
-		f = firstStruct.Field("MapStringPackageStruct")
+		f = mustField(t, parsed, firstStruct.Name, "MapStringPackageStruct")
 		require.Equal(t, "MapStringPackageStruct", f.Name)
 		require.Equal(t, "map[string]other.Struct", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = firstStruct.Field("ChanPackagePointerStruct")
+		f = mustField(t, parsed, firstStruct.Name, "ChanPackagePointerStruct")
 		require.Equal(t, "ChanPackagePointerStruct", f.Name)
 		require.Equal(t, "chan *other.Struct", f.Type)
 		require.Equal(t, false, f.Pointer)
@@ -289,64 +289,64 @@ func TestParseDirectory(t *testing.T) {
 	})
 
 	t.Run("FirstStruct", func(t *testing.T) {
-		tmp := parsed.Struct("CommentsAndDocs")
+		tmp := mustStruct(t, parsed, "CommentsAndDocs")
 
 		require.Len(t, tmp.Docs, 1)
 		require.Equal(t, "CommentsAndDocs this is the comment for the CommentsAndDocs struct.", tmp.Docs[0])
 
 		t.Run("fields comments and docs", func(t *testing.T) {
 			{
-				docs := tmp.Field("SingleDoc").Docs
+				docs := mustField(t, parsed, tmp.Name, "SingleDoc").Docs
 				require.Len(t, docs, 1)
 				require.Equal(t, "this is line 1 of comment 001", docs[0])
 			}
 			{
-				docs := tmp.Field("MultiLineDoc").Docs
+				docs := mustField(t, parsed, tmp.Name, "MultiLineDoc").Docs
 				require.Len(t, docs, 2)
 				require.Equal(t, "this is line 1 of comment 001", docs[0])
 				require.Equal(t, "this is line 2 of comment 002", docs[1])
 			}
 			{
-				docs := tmp.Field("MixedSpacesDoc").Docs
+				docs := mustField(t, parsed, tmp.Name, "MixedSpacesDoc").Docs
 				require.Len(t, docs, 2)
 				require.Equal(t, "this is line 1 of comment 003", docs[0])
 				require.Equal(t, "this is line 2 of comment 004", docs[1])
 			}
 			{
-				docs := tmp.Field("MixedTypesDoc").Docs
+				docs := mustField(t, parsed, tmp.Name, "MixedTypesDoc").Docs
 				require.Len(t, docs, 2)
 				require.Equal(t, "this is line 1 of comment 005", docs[0])
 				require.Equal(t, "this is line 2 of comment 006", docs[1])
 			}
 			{
-				docs := tmp.Field("DocAndComment").Docs
-				com := tmp.Field("DocAndComment").Comment
+				docs := mustField(t, parsed, tmp.Name, "DocAndComment").Docs
+				com := mustField(t, parsed, tmp.Name, "DocAndComment").Comment
 				require.Len(t, docs, 1)
 				require.Equal(t, "this is line 1 of comment 007", docs[0])
 				require.Equal(t, "comment 008", com)
 			}
 			{
-				docs := tmp.Field("CommentNoSpaces").Docs
-				com := tmp.Field("CommentNoSpaces").Comment
+				docs := mustField(t, parsed, tmp.Name, "CommentNoSpaces").Docs
+				com := mustField(t, parsed, tmp.Name, "CommentNoSpaces").Comment
 				require.Len(t, docs, 0)
 				require.Equal(t, "comment abc", com)
 			}
 			{
-				docs := tmp.Field("StarDoc").Docs
-				com := tmp.Field("StarDoc").Comment
+				docs := mustField(t, parsed, tmp.Name, "StarDoc").Docs
+				com := mustField(t, parsed, tmp.Name, "StarDoc").Comment
 				require.Len(t, docs, 1)
 				require.Equal(t, "this is line 1 of comment 009", docs[0])
 				require.Equal(t, "comment 010", com)
 			}
 			{
-				docs := tmp.Field("CommentWithTag").Docs
-				com := tmp.Field("CommentWithTag").Comment
+				docs := mustField(t, parsed, tmp.Name, "CommentWithTag").Docs
+				com := mustField(t, parsed, tmp.Name, "CommentWithTag").Comment
 				require.Len(t, docs, 1)
 				require.Equal(t, "this is line 1 of comment 010", docs[0])
 				require.Equal(t, "comment 11", com)
 			}
 			{
-				docs := tmp.Field("CrazyDoc").Docs
+				docs := mustField(t, parsed, tmp.Name, "CrazyDoc").Docs
 				require.Len(t, docs, 9)
 				require.Equal(t, "001", docs[0])
 				require.Equal(t, "002", docs[1])
@@ -362,27 +362,30 @@ func TestParseDirectory(t *testing.T) {
 	})
 
 	t.Run("SecondStruct", func(t *testing.T) {
-		secondStruct := parsed.Struct("SecondStruct")
+		secondStruct := mustStruct(t, parsed, "SecondStruct")
 		require.Len(t, secondStruct.Docs, 0)
 	})
 
 	// New test cases for Variables
 	t.Run("Variables", func(t *testing.T) {
-		variable := parsed.Variable("MyVariable")
+		variable, ok := parsed.Variable("MyVariable")
+		require.True(t, ok)
 		require.Equal(t, "MyVariable", variable.Name)
 		require.Equal(t, "string", variable.Type)
 	})
 
 	// New test cases for Constants
 	t.Run("Constants", func(t *testing.T) {
-		constant := parsed.Constant("MyConstant")
+		constant, ok := parsed.Constant("MyConstant")
+		require.True(t, ok)
 		require.Equal(t, "MyConstant", constant.Name)
 		require.Equal(t, `"world"`, constant.Value) // Example value
 	})
 
 	// New test cases for Functions
 	t.Run("Functions", func(t *testing.T) {
-		function := parsed.Function("MyFunction")
+		function, ok := parsed.Function("MyFunction")
+		require.True(t, ok)
 		require.Equal(t, "MyFunction", function.Name)
 		require.Equal(t, 1, len(function.Params))
 		require.Equal(t, "string", function.Params[0].Type)
@@ -396,6 +399,10 @@ func TestParseDirectory(t *testing.T) {
 		require.Equal(t, "structs", tmp.Packages[0].Package)
 	})
 
+	t.Run("Path", func(t *testing.T) {
+		require.Equal(t, "github.com/wricardo/structparser/example", tmp.Packages[0].Path)
+	})
+
 	// New test case for Imports
 	t.Run("Imports", func(t *testing.T) {
 		require.Contains(t, tmp.Packages[0].Imports, "context")
@@ -432,10 +439,10 @@ func TestFirstStructMethods(t *testing.T) {
 	tmp, err := ParseDirectory("./example/")
 	require.NoError(t, err)
 
-	parsed := newHelper(&tmp.Packages[0])
+	parsed := NewIndex(tmp)
 
 	t.Run("FirstStruct", func(t *testing.T) {
-		firstStruct := parsed.Struct("FirstStruct")
+		firstStruct := mustStruct(t, parsed, "FirstStruct")
 		require.Len(t, firstStruct.Docs, 2)
 		require.Equal(t, "FirstStruct this is the comment for the first struct.", firstStruct.Docs[0])
 		require.Len(t, firstStruct.Methods, 2)
@@ -448,12 +455,12 @@ func TestPrivateStruct(t *testing.T) {
 	tmp, err := ParseDirectory("./example/")
 	require.NoError(t, err)
 
-	parsed := newHelper(&tmp.Packages[0])
+	parsed := NewIndex(tmp)
 
 	t.Run("privateStruct", func(t *testing.T) {
-		privateStruct := parsed.Struct("privateStruct")
+		privateStruct := mustStruct(t, parsed, "privateStruct")
 
-		f := privateStruct.Field("String")
+		f := mustField(t, parsed, privateStruct.Name, "String")
 		require.Equal(t, "String", f.Name)
 		require.Equal(t, "string", f.Type)
 		require.Equal(t, false, f.Pointer)
@@ -479,21 +486,21 @@ func TestParseString(t *testing.T) {
 		output, err := ParseString(code)
 		require.NoError(t, err)
 
-		parsed := newHelper(&output.Packages[0])
-		structInfo := parsed.Struct("SimpleStruct")
+		parsed := NewIndex(output)
+		structInfo := mustStruct(t, parsed, "SimpleStruct")
 
 		require.Len(t, structInfo.Docs, 1)
 		require.Equal(t, "SimpleStruct represents a simple test case", structInfo.Docs[0])
 
 		var f Field
-		f = structInfo.Field("Name")
+		f = mustField(t, parsed, structInfo.Name, "Name")
 		require.Equal(t, "Name", f.Name)
 		require.Equal(t, "string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 		require.Equal(t, "Name is a string field", f.Comment)
 
-		f = structInfo.Field("Value")
+		f = mustField(t, parsed, structInfo.Name, "Value")
 		require.Equal(t, "Value", f.Name)
 		require.Equal(t, "int", f.Type)
 		require.Equal(t, false, f.Pointer)
@@ -519,36 +526,36 @@ func TestParseString(t *testing.T) {
 		output, err := ParseString(code)
 		require.NoError(t, err)
 
-		parsed := newHelper(&output.Packages[0])
-		structInfo := parsed.Struct("SimpleStruct")
+		parsed := NewIndex(output)
+		structInfo := mustStruct(t, parsed, "SimpleStruct")
 
 		var f Field
-		f = structInfo.Field("Name")
+		f = mustField(t, parsed, structInfo.Name, "Name")
 		require.Equal(t, "Name", f.Name)
 		require.Equal(t, "string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 		require.Equal(t, "Name is a string field", f.Comment)
 
-		f = structInfo.Field("Value")
+		f = mustField(t, parsed, structInfo.Name, "Value")
 		require.Equal(t, "Value", f.Name)
 		require.Equal(t, "int", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 		require.Equal(t, "Value is an integer field", f.Comment)
 
-		structInfo = parsed.Struct("OtherStruct")
+		structInfo = mustStruct(t, parsed, "OtherStruct")
 
 		require.Len(t, structInfo.Docs, 0)
 
-		f = structInfo.Field("Name")
+		f = mustField(t, parsed, structInfo.Name, "Name")
 		require.Equal(t, "Name", f.Name)
 		require.Equal(t, "string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 		require.Equal(t, "Name is a string field", f.Comment)
 
-		f = structInfo.Field("Value")
+		f = mustField(t, parsed, structInfo.Name, "Value")
 		require.Equal(t, "Value", f.Name)
 		require.Equal(t, "int", f.Type)
 	})
@@ -573,8 +580,8 @@ func TestParseString(t *testing.T) {
 		output, err := ParseString(code)
 		require.NoError(t, err)
 
-		parsed := newHelper(&output.Packages[0])
-		structInfo := parsed.Struct("StructWithMethods")
+		parsed := NewIndex(output)
+		structInfo := mustStruct(t, parsed, "StructWithMethods")
 
 		require.Len(t, structInfo.Methods, 2)
 
@@ -601,28 +608,28 @@ func TestParseString(t *testing.T) {
 		output, err := ParseString(code)
 		require.NoError(t, err)
 
-		parsed := newHelper(&output.Packages[0])
-		structInfo := parsed.Struct("ComplexStruct")
+		parsed := NewIndex(output)
+		structInfo := mustStruct(t, parsed, "ComplexStruct")
 
-		f := structInfo.Field("SliceOfStrings")
+		f := mustField(t, parsed, structInfo.Name, "SliceOfStrings")
 		require.Equal(t, "SliceOfStrings", f.Name)
 		require.Equal(t, "[]string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, true, f.Slice)
 
-		f = structInfo.Field("PointerToInt")
+		f = mustField(t, parsed, structInfo.Name, "PointerToInt")
 		require.Equal(t, "PointerToInt", f.Name)
 		require.Equal(t, "*int", f.Type)
 		require.Equal(t, true, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = structInfo.Field("MapOfIntToStr")
+		f = mustField(t, parsed, structInfo.Name, "MapOfIntToStr")
 		require.Equal(t, "MapOfIntToStr", f.Name)
 		require.Equal(t, "map[int]string", f.Type)
 		require.Equal(t, false, f.Pointer)
 		require.Equal(t, false, f.Slice)
 
-		f = structInfo.Field("FuncField")
+		f = mustField(t, parsed, structInfo.Name, "FuncField")
 		require.Equal(t, "FuncField", f.Name)
 		require.Equal(t, "/*func*/", f.Type)
 	})
@@ -764,8 +771,9 @@ func TestParseInterface(t *testing.T) {
 	require.NoError(t, err, "Parsing code should not result in an error")
 	require.NotNil(t, output, "Parsed output should not be nil")
 
-	parsed := newHelper(&output.Packages[0])
-	iface := parsed.Interface("Greeter")
+	parsed := NewIndex(output)
+	iface, ok := parsed.Interface("Greeter")
+	require.True(t, ok)
 
 	require.Equal(t, "Greeter", iface.Name, "Interface name should be 'Greeter'")
 
@@ -845,8 +853,8 @@ func TestParseMethodsWithBody(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, output)
 
-	parsed := newHelper(&output.Packages[0])
-	myGreeter := parsed.Struct("MyGreeter")
+	parsed := NewIndex(output)
+	myGreeter := mustStruct(t, parsed, "MyGreeter")
 
 	require.Len(t, myGreeter.Methods, 1)
 
@@ -861,3 +869,17 @@ func TestParseMethodsWithBody(t *testing.T) {
 	})
 
 }
+
+func mustStruct(t *testing.T, x *Index, name string) Struct {
+	t.Helper()
+	s, ok := x.Struct(name)
+	require.True(t, ok, "struct %s not found", name)
+	return s
+}
+
+func mustField(t *testing.T, x *Index, structName, name string) Field {
+	t.Helper()
+	f, ok := x.Field(structName, name)
+	require.True(t, ok, "field %s.%s not found", structName, name)
+	return f
+}
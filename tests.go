@@ -0,0 +1,166 @@
+package structparser
+
+import (
+	"go/ast"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TestKind classifies a function recognized by `go test`.
+type TestKind string
+
+const (
+	TestUnit      TestKind = "test"      // func TestXxx(*testing.T)
+	TestBenchmark TestKind = "benchmark" // func BenchmarkXxx(*testing.B)
+	TestFuzz      TestKind = "fuzz"      // func FuzzXxx(*testing.F)
+	TestExample   TestKind = "example"   // func ExampleXxx()
+)
+
+// Test is a test, benchmark, fuzz target or example of a package.
+type Test struct {
+	Name       string   `json:"name"`
+	Kind       TestKind `json:"kind"`
+	Target     string   `json:"target,omitempty"`    // Entity exercised, e.g. "User", "User.Save" or "Parse"
+	External   bool     `json:"external,omitempty"`  // Declared in the external _test package
+	Output     string   `json:"output,omitempty"`    // Expected output of an example
+	Unordered  bool     `json:"unordered,omitempty"` // The example output is compared without ordering
+	Docs       []string `json:"docs,omitempty"`
+	Constraint string   `json:"constraint,omitempty"` // Build constraint of the declaring file
+	Position
+}
+
+var testPrefixes = []struct {
+	prefix string
+	kind   TestKind
+	param  string
+}{
+	{"Test", TestUnit, "*testing.T"},
+	{"Benchmark", TestBenchmark, "*testing.B"},
+	{"Fuzz", TestFuzz, "*testing.F"},
+	{"Example", TestExample, ""},
+}
+
+// catalogTests moves the tests of the external foo_test package to foo when both are
+// present; its other declarations, such as helpers, stay in foo_test, which is left out
+// only when nothing else remains. Tests are then linked to the entities they exercise.
+func catalogTests(output *Output) {
+	// Packages are sorted by name, so foo always comes before foo_test
+	packagesOut := make([]Package, 0, len(output.Packages))
+	index := map[string]int{}
+	for _, pkg := range output.Packages {
+		base := strings.TrimSuffix(pkg.Package, "_test")
+		if target, ok := index[base]; ok && base != pkg.Package {
+			// The external package shows up as tests of the package under test
+			tests := pkg.Tests
+			pkg.Tests = nil
+			for i := range tests {
+				tests[i].External = true
+			}
+			packagesOut[target].Tests = append(packagesOut[target].Tests, tests...)
+			if hasDeclarations(pkg) {
+				packagesOut = append(packagesOut, pkg)
+			}
+			continue
+		}
+		index[pkg.Package] = len(packagesOut)
+		packagesOut = append(packagesOut, pkg)
+	}
+	output.Packages = packagesOut
+
+	for i := range output.Packages {
+		pkg := &output.Packages[i]
+		linkTests(pkg)
+		sort.SliceStable(pkg.Tests, func(a, b int) bool {
+			if pkg.Tests[a].File != pkg.Tests[b].File {
+				return pkg.Tests[a].File < pkg.Tests[b].File
+			}
+			return pkg.Tests[a].Line < pkg.Tests[b].Line
+		})
+	}
+}
+
+// hasDeclarations reports whether pkg declares anything besides tests.
+func hasDeclarations(pkg Package) bool {
+	return len(pkg.Structs) > 0 || len(pkg.Functions) > 0 || len(pkg.Variables) > 0 ||
+		len(pkg.Constants) > 0 || len(pkg.Interfaces) > 0 || len(pkg.Enums) > 0 || len(pkg.Types) > 0
+}
+
+// testKind reports whether fn has the name and signature `go test` looks for.
+func testKind(fn *ast.FuncDecl) (TestKind, bool) {
+	if fn == nil || fn.Type.TypeParams != nil || fn.Type.Results.NumFields() > 0 {
+		return "", false
+	}
+	name := fn.Name.Name
+	params := fn.Type.Params.List
+	for _, p := range testPrefixes {
+		if !strings.HasPrefix(name, p.prefix) {
+			continue
+		}
+		// TestXxx, where Xxx does not start with a lower case letter
+		if r, _ := utf8.DecodeRuneInString(name[len(p.prefix):]); unicode.IsLower(r) {
+			continue
+		}
+		if p.param == "" && len(params) == 0 {
+			return p.kind, true
+		}
+		if p.param != "" && fn.Type.Params.NumFields() == 1 && justTypeString(getType(params[0].Type)) == p.param {
+			return p.kind, true
+		}
+	}
+	return "", false
+}
+
+// linkTests sets the Target of tests following the naming conventions of `go test` and
+// go/doc: ExampleT_M and TestT_M exercise method M of T, ExampleF and TestF exercise F.
+func linkTests(pkg *Package) {
+	entities := map[string]bool{}
+	methods := map[string]bool{}
+	for _, s := range pkg.Structs {
+		entities[s.Name] = true
+		for _, m := range s.Methods {
+			methods[s.Name+"."+m.Name] = true
+		}
+	}
+	for _, i := range pkg.Interfaces {
+		entities[i.Name] = true
+		for _, m := range i.Methods {
+			methods[i.Name+"."+m.Name] = true
+		}
+	}
+	for _, f := range pkg.Functions {
+		if !strings.HasSuffix(f.File, "_test.go") {
+			entities[f.Name] = true
+		}
+	}
+	for _, e := range pkg.Enums {
+		entities[e.Name] = true
+	}
+
+	for i := range pkg.Tests {
+		t := &pkg.Tests[i]
+		for _, p := range testPrefixes {
+			if p.kind == t.Kind {
+				t.Target = testTarget(strings.TrimPrefix(t.Name, p.prefix), entities, methods)
+			}
+		}
+	}
+}
+
+func testTarget(name string, entities, methods map[string]bool) string {
+	parts := strings.Split(name, "_")
+	// A trailing part starting with a lower case letter is a suffix (ExampleT_suffix)
+	if n := len(parts); n > 1 {
+		if r, _ := utf8.DecodeRuneInString(parts[n-1]); unicode.IsLower(r) {
+			parts = parts[:n-1]
+		}
+	}
+	if len(parts) >= 2 && methods[parts[0]+"."+parts[1]] {
+		return parts[0] + "." + parts[1]
+	}
+	if entities[parts[0]] {
+		return parts[0]
+	}
+	return ""
+}
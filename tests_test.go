@@ -0,0 +1,103 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testSources = map[string]string{
+	"shop/order.go": `package shop
+
+type Order struct{ ID int }
+
+func (o *Order) Total() int { return 0 }
+
+func Parse(s string) (int, error) { return 0, nil }
+`,
+	"shop/order_test.go": `package shop
+
+import "testing"
+
+// TestOrder_Total checks the total
+func TestOrder_Total(t *testing.T) {}
+
+func BenchmarkParse(b *testing.B) {}
+
+func FuzzParse(f *testing.F) {}
+
+func TestMain(m *testing.M) {}
+
+func helper(t *testing.T) {}
+
+func Testlower(t *testing.T) {}
+
+func TestUnnamed(*testing.T) {}
+`,
+	"shop/example_test.go": `package shop_test
+
+import "fmt"
+
+func ExampleParse() {
+	fmt.Println("parsed")
+	// Output: parsed
+}
+
+func ExampleOrder_Total_second() {
+	fmt.Println(1)
+	fmt.Println(2)
+	// Unordered output:
+	// 2
+	// 1
+}
+
+func Example() {}
+
+type fixture struct{ name string }
+
+func check(f fixture) bool { return f.name != "" }
+`,
+}
+
+func TestCatalogTests(t *testing.T) {
+	output, err := ParseOptions{Tests: true}.ParseSources(testSources)
+	require.NoError(t, err)
+	// Tests of the external shop_test package are merged into shop, its helpers stay
+	require.Len(t, output.Packages, 2)
+	shop := output.Packages[0]
+	external := output.Packages[1]
+	require.Equal(t, "shop_test", external.Package)
+	require.Equal(t, "fixture", external.Structs[0].Name)
+	require.Equal(t, "check", external.Functions[0].Name)
+	require.Empty(t, external.Tests)
+
+	var functions []string
+	for _, f := range shop.Functions {
+		functions = append(functions, f.Name)
+	}
+	require.Equal(t, []string{"Parse", "TestMain", "Testlower", "helper"}, functions)
+
+	require.Equal(t, []Test{
+		{Name: "ExampleParse", Kind: TestExample, Target: "Parse", External: true, Output: "parsed\n",
+			Position: Position{File: "shop/example_test.go", Line: 5}},
+		{Name: "ExampleOrder_Total_second", Kind: TestExample, Target: "Order.Total", External: true, Output: "2\n1\n", Unordered: true,
+			Position: Position{File: "shop/example_test.go", Line: 10}},
+		{Name: "Example", Kind: TestExample, External: true,
+			Position: Position{File: "shop/example_test.go", Line: 18}},
+		{Name: "TestOrder_Total", Kind: TestUnit, Target: "Order.Total", Docs: []string{"TestOrder_Total checks the total"},
+			Position: Position{File: "shop/order_test.go", Line: 6}},
+		{Name: "BenchmarkParse", Kind: TestBenchmark, Target: "Parse",
+			Position: Position{File: "shop/order_test.go", Line: 8}},
+		{Name: "FuzzParse", Kind: TestFuzz, Target: "Parse",
+			Position: Position{File: "shop/order_test.go", Line: 10}},
+		{Name: "TestUnnamed", Kind: TestUnit,
+			Position: Position{File: "shop/order_test.go", Line: 18}},
+	}, shop.Tests)
+
+	// Test files are left out unless asked for
+	output, err = ParseSources(testSources)
+	require.NoError(t, err)
+	require.Len(t, output.Packages, 1)
+	require.Empty(t, output.Packages[0].Tests)
+	require.Len(t, output.Packages[0].Functions, 1)
+}
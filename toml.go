@@ -0,0 +1,194 @@
+package structparser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlEncoding writes Output as TOML, using arrays of tables for lists of objects
+// (e.g. [[packages.structs.fields]]). TOML has no null, so null values are omitted.
+// Decoding accepts any TOML 1.0 document.
+type tomlEncoding struct{}
+
+func (tomlEncoding) Encode(w io.Writer, out *Output) error {
+	tree, err := orderedTree(out)
+	if err != nil {
+		return err
+	}
+	root, ok := tree.(orderedMap)
+	if !ok {
+		return errors.New("toml: top level value must be an object")
+	}
+	var buf bytes.Buffer
+	bw := bufio.NewWriter(&buf)
+	if err := writeTOMLTable(bw, nil, root); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	_, err = w.Write(bytes.TrimLeft(buf.Bytes(), "\n"))
+	return err
+}
+
+func (tomlEncoding) Decode(r io.Reader) (*Output, error) {
+	var tree map[string]interface{}
+	if _, err := toml.NewDecoder(r).Decode(&tree); err != nil {
+		return nil, err
+	}
+	return outputFromTree(tree)
+}
+
+var tomlBareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if tomlBareKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+func tomlPath(path []string) string {
+	keys := make([]string, len(path))
+	for i, p := range path {
+		keys[i] = tomlKey(p)
+	}
+	return strings.Join(keys, ".")
+}
+
+// isTOMLTableArray reports whether v is a non-empty list made only of objects.
+func isTOMLTableArray(v interface{}) bool {
+	list, ok := v.([]interface{})
+	if !ok || len(list) == 0 {
+		return false
+	}
+	for _, item := range list {
+		if _, ok := item.(orderedMap); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func writeTOMLTable(w *bufio.Writer, path []string, table orderedMap) error {
+	// Plain keys have to come before any sub-table of the same table
+	for _, e := range table {
+		if e.Value == nil {
+			continue
+		}
+		if _, ok := e.Value.(orderedMap); ok || isTOMLTableArray(e.Value) {
+			continue
+		}
+		value, err := tomlValue(e.Value)
+		if err != nil {
+			return fmt.Errorf("toml: %s: %w", tomlPath(append(path, e.Key)), err)
+		}
+		fmt.Fprintf(w, "%s = %s\n", tomlKey(e.Key), value)
+	}
+	for _, e := range table {
+		childPath := append(append([]string{}, path...), e.Key)
+		switch v := e.Value.(type) {
+		case orderedMap:
+			fmt.Fprintf(w, "\n[%s]\n", tomlPath(childPath))
+			if err := writeTOMLTable(w, childPath, v); err != nil {
+				return err
+			}
+		case []interface{}:
+			if !isTOMLTableArray(v) {
+				continue
+			}
+			for _, item := range v {
+				fmt.Fprintf(w, "\n[[%s]]\n", tomlPath(childPath))
+				if err := writeTOMLTable(w, childPath, item.(orderedMap)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func tomlValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case string:
+		return tomlString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return v.String(), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return "", err
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eE") {
+			// TOML floats need a fractional part or exponent
+			s += ".0"
+		}
+		return s, nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := tomlValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return "[" + strings.Join(items, ", ") + "]", nil
+	case orderedMap:
+		items := make([]string, 0, len(v))
+		for _, e := range v {
+			if e.Value == nil {
+				continue
+			}
+			s, err := tomlValue(e.Value)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, tomlKey(e.Key)+" = "+s)
+		}
+		return "{" + strings.Join(items, ", ") + "}", nil
+	case nil:
+		return "", errors.New("null values are not supported inside arrays")
+	}
+	return "", fmt.Errorf("unsupported value %T", v)
+}
+
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,250 @@
+package structparser
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+// parseTypeString turns a type string produced by getType back into an expression so
+// emitters can walk it. It returns nil for placeholders such as "/*func*/".
+func parseTypeString(typeString string) ast.Expr {
+	if typeString == "" || strings.HasPrefix(typeString, "/*") {
+		return nil
+	}
+	expr, err := parser.ParseExpr(typeString)
+	if err != nil {
+		return nil
+	}
+	return expr
+}
+
+// lookupTag returns the name and options of a struct tag key, e.g. `json:"name,omitempty"`
+// gives "name" and ["omitempty"].
+func lookupTag(tag, key string) (name string, options []string, ok bool) {
+	value, ok := reflect.StructTag(tag).Lookup(key)
+	if !ok {
+		return "", nil, false
+	}
+	parts := strings.Split(value, ",")
+	return parts[0], parts[1:], true
+}
+
+// tagKeys returns the keys of a conventional struct tag in order, e.g. `json:"id" db:"id"`
+// gives ["json", "db"]. Parsing stops at the first malformed pair, like reflect.StructTag.
+func tagKeys(tag string) []string {
+	var keys []string
+	for {
+		tag = strings.TrimLeft(tag, " ")
+		i := 0
+		for i < len(tag) && tag[i] > ' ' && tag[i] != ':' && tag[i] != '"' && tag[i] != 0x7f {
+			i++
+		}
+		if i == 0 || i+1 >= len(tag) || tag[i] != ':' || tag[i+1] != '"' {
+			return keys
+		}
+		key := tag[:i]
+		tag = tag[i+1:]
+
+		// Skip the quoted value
+		i = 1
+		for i < len(tag) && tag[i] != '"' {
+			if tag[i] == '\\' {
+				i++
+			}
+			i++
+		}
+		if i >= len(tag) {
+			return keys
+		}
+		keys = append(keys, key)
+		tag = tag[i+1:]
+	}
+}
+
+func hasTagOption(options []string, option string) bool {
+	for _, o := range options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonName returns the name a field is serialized with by encoding/json, whether it is
+// marked omitempty and whether it is serialized at all.
+func jsonName(f Field) (name string, omitEmpty, ok bool) {
+	if f.Private {
+		return "", false, false
+	}
+	name, options, _ := lookupTag(f.Tag, "json")
+	if name == "-" && len(options) == 0 {
+		return "", false, false
+	}
+	if name == "" {
+		name = f.Name
+	}
+	return name, hasTagOption(options, "omitempty"), true
+}
+
+// embeddedTypeName returns the type name of an embedded field without pointer and
+// package qualifier, or "" if the field is not embedded.
+func embeddedTypeName(f Field) string {
+	if f.Name != "" {
+		return ""
+	}
+	name := strings.TrimPrefix(f.Type, "*")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// importedPackage returns the package of out that the qualifier name refers to in package
+// from: among the packages with that name, the one from imports, or else the only one.
+func importedPackage(out *Output, from Package, name string) (Package, bool) {
+	var candidates []Package
+	for _, pkg := range out.Packages {
+		if pkg.Package != name {
+			continue
+		}
+		for _, path := range from.Imports {
+			if pkg.Path != "" && path == pkg.Path {
+				return pkg, true
+			}
+		}
+		candidates = append(candidates, pkg)
+	}
+	if len(candidates) == 1 {
+		return candidates[0], true
+	}
+	return Package{}, false
+}
+
+// qualifyType rewrites the type names of a type string of package pkg to be qualified by
+// import path, so types strings of different packages can be compared: "User" becomes
+// "example.com/b.User" and "io.Reader" becomes "io.Reader" ("io" resolved through the
+// imports of pkg). Predeclared types are left alone. Type strings that do not parse are
+// returned unchanged.
+func qualifyType(pkg Package, typeString string) string {
+	expr := parseTypeString(typeString)
+	if expr == nil {
+		return typeString
+	}
+	return types.ExprString(qualifyExpr(pkg, expr))
+}
+
+func qualifyExpr(pkg Package, expr ast.Expr) ast.Expr {
+	qualifyFields := func(list *ast.FieldList) {
+		if list == nil {
+			return
+		}
+		for _, f := range list.List {
+			f.Type = qualifyExpr(pkg, f.Type)
+		}
+	}
+	switch e := expr.(type) {
+	case *ast.Ident:
+		if obj := types.Universe.Lookup(e.Name); obj != nil {
+			return e
+		}
+		return ast.NewIdent(entityPackage(pkg) + "." + e.Name)
+	case *ast.SelectorExpr:
+		if x, ok := e.X.(*ast.Ident); ok {
+			return ast.NewIdent(importPathOf(pkg, x.Name) + "." + e.Sel.Name)
+		}
+	case *ast.StarExpr:
+		e.X = qualifyExpr(pkg, e.X)
+	case *ast.ParenExpr:
+		e.X = qualifyExpr(pkg, e.X)
+	case *ast.Ellipsis:
+		e.Elt = qualifyExpr(pkg, e.Elt)
+	case *ast.ArrayType:
+		e.Elt = qualifyExpr(pkg, e.Elt)
+	case *ast.MapType:
+		e.Key = qualifyExpr(pkg, e.Key)
+		e.Value = qualifyExpr(pkg, e.Value)
+	case *ast.ChanType:
+		e.Value = qualifyExpr(pkg, e.Value)
+	case *ast.FuncType:
+		qualifyFields(e.Params)
+		qualifyFields(e.Results)
+	case *ast.StructType:
+		qualifyFields(e.Fields)
+	case *ast.InterfaceType:
+		qualifyFields(e.Methods)
+	case *ast.IndexExpr:
+		e.X = qualifyExpr(pkg, e.X)
+		e.Index = qualifyExpr(pkg, e.Index)
+	case *ast.IndexListExpr:
+		e.X = qualifyExpr(pkg, e.X)
+		for k, index := range e.Indices {
+			e.Indices[k] = qualifyExpr(pkg, index)
+		}
+	}
+	return expr
+}
+
+// entityPackage names pkg by import path, or by name when the path is unknown.
+func entityPackage(pkg Package) string {
+	if pkg.Path != "" {
+		return pkg.Path
+	}
+	return pkg.Package
+}
+
+// importPathOf returns the path of the import of pkg that the qualifier name refers to,
+// matching it against the last element of import paths, ignoring major version suffixes
+// such as "/v2" and ".v3". It returns name itself when no import matches.
+func importPathOf(pkg Package, name string) string {
+	for _, path := range pkg.Imports {
+		elems := strings.Split(path, "/")
+		last := elems[len(elems)-1]
+		if len(elems) > 1 && isMajorVersion(last) {
+			last = elems[len(elems)-2]
+		}
+		if i := strings.LastIndex(last, "."); i > 0 && isMajorVersion(last[i+1:]) {
+			last = last[:i]
+		}
+		last = strings.TrimPrefix(strings.TrimPrefix(last, "go-"), "go.")
+		if last == name || strings.ReplaceAll(last, "-", "") == name {
+			return path
+		}
+	}
+	return name
+}
+
+func isMajorVersion(s string) bool {
+	if len(s) < 2 || s[0] != 'v' {
+		return false
+	}
+	for _, c := range s[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// funcTypeString formats the type of a function with the given parameters and results,
+// e.g. "func(string, int) (bool, error)".
+func funcTypeString(params, returns []Param) string {
+	types := func(list []Param) []string {
+		out := make([]string, len(list))
+		for i, p := range list {
+			out[i] = p.Type
+		}
+		return out
+	}
+	sig := "func(" + strings.Join(types(params), ", ") + ")"
+	switch results := types(returns); len(results) {
+	case 0:
+	case 1:
+		sig += " " + results[0]
+	default:
+		sig += " (" + strings.Join(results, ", ") + ")"
+	}
+	return sig
+}
@@ -0,0 +1,230 @@
+package structparser
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"sort"
+	"strings"
+)
+
+// TypeScriptOptions configures GenerateTypeScript.
+type TypeScriptOptions struct {
+	// ImportPath returns the module to import for a referenced Go package name.
+	// Defaults to "./<package>".
+	ImportPath func(pkg string) string
+}
+
+// GenerateTypeScript produces TypeScript declarations mirroring the JSON shape of the
+// package's structs and enums.
+func GenerateTypeScript(pkg *Package, opts TypeScriptOptions) ([]byte, error) {
+	if opts.ImportPath == nil {
+		opts.ImportPath = func(pkg string) string { return "./" + pkg }
+	}
+
+	g := &tsGenerator{
+		local:      map[string]bool{},
+		underlying: map[string]string{},
+		imports:    map[string]bool{},
+	}
+	for _, s := range pkg.Structs {
+		g.local[s.Name] = true
+	}
+	enums := map[string]bool{}
+	for _, e := range pkg.Enums {
+		enums[e.Name] = true
+		if ast.IsExported(e.Name) {
+			g.local[e.Name] = true
+		} else {
+			g.underlying[e.Name] = e.Type
+		}
+	}
+	// Other named types, such as "type UserID int64", are declared as aliases when
+	// exported and replaced by their underlying type otherwise
+	var named []Type
+	for _, t := range pkg.Types {
+		if (t.Kind == "named" || t.Kind == "alias") && t.Underlying != "" && !enums[t.Name] {
+			if ast.IsExported(t.Name) {
+				g.local[t.Name] = true
+				named = append(named, t)
+			} else {
+				g.underlying[t.Name] = t.Underlying
+			}
+		}
+	}
+
+	var body bytes.Buffer
+	for _, t := range named {
+		fmt.Fprintf(&body, "export type %s = %s;\n\n", t.Name, g.typeOf(parseTypeString(t.Underlying)))
+	}
+	for _, e := range pkg.Enums {
+		if !ast.IsExported(e.Name) {
+			continue
+		}
+		writeJSDoc(&body, "", e.Docs)
+		values := make([]string, 0, len(e.Values))
+		for _, v := range e.Values {
+			values = append(values, v.Value)
+		}
+		fmt.Fprintf(&body, "export type %s = %s;\n\n", e.Name, strings.Join(values, " | "))
+	}
+
+	for _, s := range pkg.Structs {
+		extends := []string{}
+		fields := []string{}
+		for _, f := range s.Fields {
+			if f.Name == "" {
+				if name, _, _ := lookupTag(f.Tag, "json"); name == "" {
+					// Embedded structs are flattened by encoding/json
+					extends = append(extends, g.typeOf(parseTypeString(strings.TrimPrefix(f.Type, "*"))))
+					continue
+				}
+				f.Name = embeddedTypeName(f)
+			}
+			name, optional, ok := jsonName(f)
+			if !ok {
+				continue
+			}
+
+			var field bytes.Buffer
+			docs := append([]string{}, f.Docs...)
+			if f.Comment != "" {
+				docs = append(docs, f.Comment)
+			}
+			writeJSDoc(&field, "  ", docs)
+			name = tsPropertyName(name)
+			if optional {
+				name += "?"
+			}
+			fmt.Fprintf(&field, "  %s: %s;\n", name, g.typeOf(parseTypeString(f.Type)))
+			fields = append(fields, field.String())
+		}
+
+		// encoding/json encodes the exported fields of unexported structs too, so they are
+		// declared for the fields using them, without being exported
+		writeJSDoc(&body, "", s.Docs)
+		if ast.IsExported(s.Name) {
+			body.WriteString("export ")
+		}
+		fmt.Fprintf(&body, "interface %s", s.Name)
+		if len(extends) > 0 {
+			fmt.Fprintf(&body, " extends %s", strings.Join(extends, ", "))
+		}
+		fmt.Fprintf(&body, " {\n%s}\n\n", strings.Join(fields, ""))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by structparser. DO NOT EDIT.\n\n")
+	imports := make([]string, 0, len(g.imports))
+	for k := range g.imports {
+		imports = append(imports, k)
+	}
+	sort.Strings(imports)
+	for _, k := range imports {
+		fmt.Fprintf(&buf, "import type * as %s from %q;\n", k, opts.ImportPath(k))
+	}
+	if len(imports) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.Write(bytes.TrimRight(body.Bytes(), "\n"))
+	buf.WriteString("\n")
+	return buf.Bytes(), nil
+}
+
+type tsGenerator struct {
+	local      map[string]bool   // Types declared in the package being generated
+	underlying map[string]string // Unexported named types of the package, by name
+	imports    map[string]bool   // Packages referenced by the generated types
+	resolving  []string          // Named types being replaced, to stop on recursive types
+}
+
+// typeOf maps a Go type expression to the TypeScript type of its JSON encoding.
+func (g *tsGenerator) typeOf(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		switch e.Name {
+		case "string":
+			return "string"
+		case "bool":
+			return "boolean"
+		case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64",
+			"uintptr", "float32", "float64", "byte", "rune":
+			return "number"
+		case "any":
+			return "unknown"
+		}
+		if g.local[e.Name] {
+			return e.Name
+		}
+		if u, ok := g.underlying[e.Name]; ok && !containsName(g.resolving, e.Name) {
+			g.resolving = append(g.resolving, e.Name)
+			defer func() { g.resolving = g.resolving[:len(g.resolving)-1] }()
+			return g.typeOf(parseTypeString(u))
+		}
+	case *ast.SelectorExpr:
+		pkg, _ := e.X.(*ast.Ident)
+		if pkg == nil {
+			break
+		}
+		switch pkg.Name + "." + e.Sel.Name {
+		case "time.Time":
+			return "string"
+		case "time.Duration":
+			return "number"
+		case "json.RawMessage":
+			return "unknown"
+		}
+		g.imports[pkg.Name] = true
+		return pkg.Name + "." + e.Sel.Name
+	case *ast.StarExpr:
+		return g.typeOf(e.X) + " | null"
+	case *ast.ArrayType:
+		if ident, ok := e.Elt.(*ast.Ident); ok && (ident.Name == "byte" || ident.Name == "uint8") && e.Len == nil {
+			// []byte is encoded as a base64 string
+			return "string"
+		}
+		elem := g.typeOf(e.Elt)
+		if strings.Contains(elem, " ") {
+			elem = "(" + elem + ")"
+		}
+		return elem + "[]"
+	case *ast.MapType:
+		key := g.typeOf(e.Key)
+		if key != "number" {
+			key = "string"
+		}
+		return fmt.Sprintf("Record<%s, %s>", key, g.typeOf(e.Value))
+	}
+	return "unknown"
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSDoc(buf *bytes.Buffer, indent string, docs []string) {
+	if len(docs) == 0 {
+		return
+	}
+	buf.WriteString(indent + "/**\n")
+	for _, d := range docs {
+		fmt.Fprintf(buf, "%s * %s\n", indent, strings.ReplaceAll(d, "*/", "*\\/"))
+	}
+	buf.WriteString(indent + " */\n")
+}
+
+// tsPropertyName quotes property names that are not valid identifiers.
+func tsPropertyName(name string) string {
+	for i, r := range name {
+		if r == '_' || r == '$' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9' {
+			continue
+		}
+		return fmt.Sprintf("%q", name)
+	}
+	return name
+}
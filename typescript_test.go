@@ -0,0 +1,98 @@
+package structparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateTypeScript(t *testing.T) {
+	code := `
+	package api
+
+	import "time"
+
+	// Color of a widget
+	type Color string
+
+	const (
+		// Red is red
+		Red   Color = "red"
+		Green Color = "green"
+	)
+
+	type Level int
+
+	const (
+		Low Level = iota + 1
+		High
+	)
+
+	type UserID int64
+
+	type secret string
+
+	type level int
+
+	const (
+		debug level = iota
+		trace
+	)
+
+	type Base struct {
+		ID int ` + "`json:\"id\"`" + `
+	}
+
+	// Widget is a thing
+	type Widget struct {
+		Base
+		Name     string            ` + "`json:\"name\"`" + ` // display name
+		Color    Color             ` + "`json:\"color,omitempty\"`" + `
+		Level    *Level            ` + "`json:\"level\"`" + `
+		Tags     []string          ` + "`json:\"tags\"`" + `
+		Labels   map[string]int    ` + "`json:\"labels\"`" + `
+		Owner    *other.User       ` + "`json:\"owner\"`" + `
+		Created  time.Time         ` + "`json:\"created_at\"`" + `
+		Secret   string            ` + "`json:\"-\"`" + `
+		Owner2   UserID            ` + "`json:\"owner_id\"`" + `
+		Token    secret            ` + "`json:\"token\"`" + `
+		Verbose  level             ` + "`json:\"verbose\"`" + `
+		internal string
+		Stats    stats             ` + "`json:\"stats\"`" + `
+	}
+
+	type stats struct {
+		Views int ` + "`json:\"views\"`" + `
+	}
+	`
+	output, err := ParseString(code)
+	require.NoError(t, err)
+
+	pkg := output.Packages[0]
+	require.Len(t, pkg.Enums, 3)
+
+	ts, err := GenerateTypeScript(&pkg, TypeScriptOptions{})
+	require.NoError(t, err)
+	got := string(ts)
+
+	require.Contains(t, got, `import type * as other from "./other";`)
+	require.Contains(t, got, "/**\n * Color of a widget\n */\nexport type Color = \"red\" | \"green\";")
+	require.Contains(t, got, "export type Level = 1 | 2;")
+	require.Contains(t, got, "export interface Widget extends Base {")
+	require.Contains(t, got, "  /**\n   * display name\n   */\n  name: string;")
+	require.Contains(t, got, "  color?: Color;")
+	require.Contains(t, got, "  level: Level | null;")
+	require.Contains(t, got, "  tags: string[];")
+	require.Contains(t, got, "  labels: Record<string, number>;")
+	require.Contains(t, got, "  owner: other.User | null;")
+	require.Contains(t, got, "  created_at: string;")
+	require.Contains(t, got, "export type UserID = number;")
+	require.Contains(t, got, "  owner_id: UserID;")
+	require.Contains(t, got, "  token: string;")
+	require.Contains(t, got, "  verbose: number;")
+	require.NotContains(t, got, "export type level")
+	require.Contains(t, got, "  stats: stats;")
+	require.Contains(t, got, "\ninterface stats {\n  views: number;\n}")
+	require.NotContains(t, got, "Secret")
+	require.NotContains(t, got, "internal")
+}
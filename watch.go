@@ -0,0 +1,255 @@
+package structparser
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WatchOptions configures Workspace.Watch.
+type WatchOptions struct {
+	Debounce time.Duration // Quiet period before a burst of changes is applied, defaults to 100ms
+	Interval time.Duration // Polling interval, defaults to 1s
+	Poll     bool          // Poll modification times even where file system notifications are available
+}
+
+// notifier reports the paths of .go files that were created, written or removed.
+type notifier interface {
+	run(ctx context.Context, out chan<- string) error
+}
+
+// Watch monitors the directories loaded in the workspace and applies changes to their Go
+// files as they happen. Under the directory of a "dir/..." pattern given to Load, new
+// packages are picked up too. File system notifications are used where supported
+// (inotify on Linux), with polling as the fallback. Changes are debounced, so that saving
+// several files calls onChange once with the combined change set, and the error of the
+// first package that failed to parse, if any. Watch blocks until ctx is done.
+func (w *Workspace) Watch(ctx context.Context, opts WatchOptions, onChange func(ChangeSet, error)) error {
+	opts = opts.withDefaults()
+	return w.watch(ctx, w.notifier(opts), opts, onChange)
+}
+
+func (o WatchOptions) withDefaults() WatchOptions {
+	if o.Debounce <= 0 {
+		o.Debounce = 100 * time.Millisecond
+	}
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	return o
+}
+
+// notifier returns a notifier for the directories of the workspace. Changes made once it
+// returns are reported.
+func (w *Workspace) notifier(opts WatchOptions) notifier {
+	dirs, roots := w.watchedDirs()
+	if !opts.Poll {
+		if inotify, err := newNotifier(dirs, roots); err == nil {
+			return inotify
+		}
+	}
+	return newPollNotifier(dirs, roots, opts.Interval)
+}
+
+func (w *Workspace) watch(ctx context.Context, n notifier, opts WatchOptions, onChange func(ChangeSet, error)) error {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := make(chan string, 64)
+	errc := make(chan error, 1)
+	go func() { errc <- n.run(runCtx, events) }()
+
+	pending := map[string]bool{}
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-errc:
+			return err
+		case path := <-events:
+			pending[path] = true
+			debounce = time.After(opts.Debounce)
+		case <-debounce:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			pending = map[string]bool{}
+			debounce = nil
+			if changes, err := w.sync(paths); err != nil || !changes.Empty() {
+				onChange(changes, err)
+			}
+		}
+	}
+}
+
+// watchedDirs returns the loaded directories and the roots of recursive patterns.
+func (w *Workspace) watchedDirs() (dirs, roots []string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dirs = make([]string, 0, len(w.dirs))
+	for dir := range w.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	roots = make([]string, 0, len(w.roots))
+	for root := range w.roots {
+		roots = append(roots, root)
+	}
+	sort.Strings(roots)
+	return dirs, roots
+}
+
+// walkTree calls fn for root and every directory below it that a "dir/..." pattern
+// would match, whether or not it has Go files yet.
+func walkTree(root string, fn func(dir string)) {
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != root && ignoredDir(d.Name()) {
+			return filepath.SkipDir
+		}
+		fn(path)
+		return nil
+	})
+}
+
+// sync reads paths from disk into the workspace, then re-parses every affected package
+// once.
+func (w *Workspace) sync(paths []string) (ChangeSet, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var firstErr error
+	touched := map[string]*workspaceDir{}
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return ChangeSet{}, err
+		}
+		dir := filepath.Dir(abs)
+		d, err := w.loadDir(dir)
+		if err != nil {
+			return ChangeSet{}, err
+		}
+		touched[dir] = d
+
+		info, err := os.Stat(abs)
+		switch {
+		case os.IsNotExist(err):
+			delete(d.files, abs)
+			continue
+		case err != nil:
+			return ChangeSet{}, err
+		case !info.Mode().IsRegular() || w.opts.Filter != nil && !w.opts.Filter(info):
+			continue
+		}
+		content, err := os.ReadFile(abs)
+		if err != nil {
+			return ChangeSet{}, err
+		}
+		d.files[abs] = content
+	}
+
+	var changes ChangeSet
+	dirs := make([]string, 0, len(touched))
+	for dir := range touched {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		d := touched[dir]
+		changes.merge(w.reparse(context.Background(), dir, d))
+		if d.err != nil && firstErr == nil {
+			firstErr = d.err
+		}
+	}
+	return changes, firstErr
+}
+
+// pollNotifier compares the size and modification time of the .go files of its
+// directories, and of every directory under its roots, at every interval.
+type pollNotifier struct {
+	dirs     []string
+	roots    []string
+	interval time.Duration
+	state    map[string]fileStamp
+}
+
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func newPollNotifier(dirs, roots []string, interval time.Duration) *pollNotifier {
+	p := &pollNotifier{dirs: dirs, roots: roots, interval: interval}
+	p.state = p.scan()
+	return p
+}
+
+func (p *pollNotifier) scan() map[string]fileStamp {
+	seen := map[string]bool{}
+	state := map[string]fileStamp{}
+	scanDir := func(dir string) {
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") {
+				continue
+			}
+			if info, err := entry.Info(); err == nil {
+				state[filepath.Join(dir, entry.Name())] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+			}
+		}
+	}
+	for _, dir := range p.dirs {
+		scanDir(dir)
+	}
+	for _, root := range p.roots {
+		walkTree(root, scanDir)
+	}
+	return state
+}
+
+func (p *pollNotifier) run(ctx context.Context, out chan<- string) error {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		state := p.scan()
+		var changed []string
+		for path, stamp := range state {
+			if old, ok := p.state[path]; !ok || old.size != stamp.size || !old.modTime.Equal(stamp.modTime) {
+				changed = append(changed, path)
+			}
+		}
+		for path := range p.state {
+			if _, ok := state[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		p.state = state
+		sort.Strings(changed)
+		for _, path := range changed {
+			select {
+			case out <- path:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
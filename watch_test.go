@@ -0,0 +1,135 @@
+package structparser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspaceWatch(t *testing.T) {
+	for _, poll := range []bool{false, true} {
+		dirs := writePackages(t, 1)
+		root := filepath.Dir(dirs[0])
+		ws := NewWorkspace(ParseOptions{})
+		_, err := ws.Load(context.Background(), root+"/...")
+		require.NoError(t, err)
+
+		type update struct {
+			changes ChangeSet
+			err     error
+		}
+		updates := make(chan update, 10)
+		ctx, cancel := context.WithCancel(context.Background())
+		opts := WatchOptions{Debounce: 10 * time.Millisecond, Interval: 10 * time.Millisecond, Poll: poll}.withDefaults()
+		// The notifier is set up before returning, so the writes below are all seen
+		n := ws.notifier(opts)
+		done := make(chan error, 1)
+		go func() {
+			done <- ws.watch(ctx, n, opts, func(changes ChangeSet, err error) { updates <- update{changes, err} })
+		}()
+
+		// Changes may come in one update or several; wait until they all are in
+		waitFor := func(step string, until func(update) bool) {
+			t.Helper()
+			timeout := time.After(10 * time.Second)
+			for {
+				select {
+				case u := <-updates:
+					if until(u) {
+						return
+					}
+				case <-timeout:
+					t.Fatalf("poll=%t: %s: no update", poll, step)
+				}
+			}
+		}
+		addedNames := func(u update, names map[string]bool) {
+			for _, e := range u.changes.Added {
+				names[e.Name] = true
+			}
+		}
+
+		order := filepath.Join(dirs[0], "order.go")
+		require.NoError(t, os.WriteFile(order, []byte("package pkg00\n\ntype Order struct{ Items []Item }\n"), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dirs[0], "item.go"), []byte("package pkg00\n\ntype Item struct{}\n"), 0o644))
+		added := map[string]bool{}
+		waitFor("add", func(u update) bool {
+			require.NoError(t, u.err, "poll=%t", poll)
+			addedNames(u, added)
+			return added["Order"] && added["Item"]
+		})
+
+		require.NoError(t, os.Remove(order))
+		waitFor("remove", func(u update) bool {
+			require.NoError(t, u.err, "poll=%t", poll)
+			for _, e := range u.changes.Removed {
+				if e.Name == "Order" {
+					return true
+				}
+			}
+			return false
+		})
+
+		require.NoError(t, os.WriteFile(order, []byte("package pkg00\n\ntype Order struct {\n"), 0o644))
+		waitFor("error", func(u update) bool { return u.err != nil })
+
+		// A package created under the root of the pattern is picked up
+		shipping := filepath.Join(root, "shipping")
+		require.NoError(t, os.Mkdir(shipping, 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(shipping, "shipment.go"), []byte("package shipping\n\ntype Shipment struct{}\n"), 0o644))
+		added = map[string]bool{}
+		waitFor("new package", func(u update) bool {
+			addedNames(u, added)
+			return added["Shipment"]
+		})
+
+		cancel()
+		require.ErrorIs(t, <-done, context.Canceled)
+	}
+}
+
+// sliceNotifier reports its paths, then waits for the context to be done.
+type sliceNotifier []string
+
+func (n sliceNotifier) run(ctx context.Context, out chan<- string) error {
+	for _, path := range n {
+		out <- path
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestWorkspaceWatchDebounce(t *testing.T) {
+	dirs := writePackages(t, 1)
+	ws := NewWorkspace(ParseOptions{})
+	_, err := ws.Load(context.Background(), dirs...)
+	require.NoError(t, err)
+
+	order := filepath.Join(dirs[0], "order.go")
+	item := filepath.Join(dirs[0], "item.go")
+	require.NoError(t, os.WriteFile(order, []byte("package pkg00\n\ntype Order struct{ Items []Item }\n"), 0o644))
+	require.NoError(t, os.WriteFile(item, []byte("package pkg00\n\ntype Item struct{}\n"), 0o644))
+
+	// Two files saved together make a single update
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan ChangeSet, 10)
+	done := make(chan error, 1)
+	go func() {
+		done <- ws.watch(ctx, sliceNotifier{order, item}, WatchOptions{Debounce: time.Second}.withDefaults(),
+			func(changes ChangeSet, err error) {
+				require.NoError(t, err)
+				updates <- changes
+			})
+	}()
+	changes := <-updates
+	require.Len(t, changes.Added, 2)
+
+	cancel()
+	require.ErrorIs(t, <-done, context.Canceled)
+	require.Empty(t, updates)
+}
@@ -0,0 +1,283 @@
+package structparser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Entity identifies a top-level declaration of a package.
+type Entity struct {
+	Package string `json:"package"` // Import path, or package name when the path is unknown
+	Kind    string `json:"kind"`    // struct, interface, function, variable, constant, enum or test
+	Name    string `json:"name"`
+	Position
+}
+
+// ChangeSet lists the entities affected by a Workspace update. Entities are modified when
+// anything but their position changed, including their fields and methods.
+type ChangeSet struct {
+	Added    []Entity `json:"added,omitempty"`
+	Removed  []Entity `json:"removed,omitempty"`
+	Modified []Entity `json:"modified,omitempty"`
+}
+
+// Empty reports whether nothing changed.
+func (c ChangeSet) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Modified) == 0
+}
+
+// Workspace holds parsed packages in memory for long running processes such as editors
+// and watchers. Files are read from disk when their directory is first loaded; later
+// updates come from UpdateFile and RemoveFile, and only the package of the file is
+// extracted again. A Workspace is safe for concurrent use.
+type Workspace struct {
+	opts ParseOptions
+
+	mu    sync.Mutex
+	dirs  map[string]*workspaceDir
+	roots map[string]bool // Directories of the "dir/..." patterns loaded, watched for new packages
+}
+
+type workspaceDir struct {
+	files  map[string][]byte // Absolute file name to content
+	output *Output           // Last successful parse
+	err    error             // Error of the last parse, if it failed
+}
+
+// NewWorkspace returns an empty workspace parsing with opts.
+func NewWorkspace(opts ParseOptions) *Workspace {
+	return &Workspace{opts: opts, dirs: map[string]*workspaceDir{}, roots: map[string]bool{}}
+}
+
+// Load reads and parses the packages matched by patterns, as understood by ParseContext.
+// Packages that fail to parse are reported in the diagnostics of Output. Directories that
+// cannot be walked do not stop the others from loading; the first such error is returned
+// along with the changes.
+func (w *Workspace) Load(ctx context.Context, patterns ...string) (ChangeSet, error) {
+	dirs, walkErrs := expandPatterns(ctx, patterns)
+	if err := ctx.Err(); err != nil {
+		return ChangeSet{}, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, pattern := range patterns {
+		if root, ok := recursiveRoot(pattern); ok {
+			abs, err := filepath.Abs(root)
+			if err != nil {
+				return ChangeSet{}, err
+			}
+			w.roots[abs] = true
+		}
+	}
+	var changes ChangeSet
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return changes, err
+		}
+		if fi, err := os.Stat(dir); err == nil && fi.Mode().IsRegular() {
+			dir = filepath.Dir(dir)
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return changes, err
+		}
+		d, err := w.loadDir(abs)
+		if err != nil {
+			return changes, err
+		}
+		changes.merge(w.reparse(ctx, abs, d))
+	}
+	if len(walkErrs) > 0 {
+		return changes, fmt.Errorf("%s: %w", walkErrs[0].path, walkErrs[0].err)
+	}
+	return changes, nil
+}
+
+// UpdateFile sets the content of a Go file, which does not need to exist on disk, and
+// re-parses its package. When the package no longer parses, the error is returned along
+// with an empty change set, and the previous entities are kept until it parses again.
+func (w *Workspace) UpdateFile(path string, content []byte) (ChangeSet, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	d, err := w.loadDir(filepath.Dir(abs))
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	d.files[abs] = append([]byte(nil), content...)
+	changes := w.reparse(context.Background(), filepath.Dir(abs), d)
+	return changes, d.err
+}
+
+// RemoveFile forgets a file and re-parses its package. Removing the last file of a
+// directory removes its packages.
+func (w *Workspace) RemoveFile(path string) (ChangeSet, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return ChangeSet{}, err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dir := filepath.Dir(abs)
+	d, ok := w.dirs[dir]
+	if !ok {
+		return ChangeSet{}, nil
+	}
+	delete(d.files, abs)
+	changes := w.reparse(context.Background(), dir, d)
+	if len(d.files) == 0 {
+		delete(w.dirs, dir)
+	}
+	return changes, d.err
+}
+
+// Output returns the packages of the workspace ordered by directory, with a diagnostic
+// for every package that currently fails to parse.
+func (w *Workspace) Output() *Output {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	dirs := make([]string, 0, len(w.dirs))
+	for dir := range w.dirs {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	output := &Output{SchemaVersion: SchemaVersion, Packages: []Package{}}
+	for _, dir := range dirs {
+		d := w.dirs[dir]
+		if d.output != nil {
+			output.Packages = append(output.Packages, d.output.Packages...)
+		}
+		if d.err != nil {
+			output.Diagnostics = append(output.Diagnostics, diagnostics(dir, d.err)...)
+		}
+	}
+	return output
+}
+
+// loadDir returns the state of dir, reading its files from disk the first time.
+func (w *Workspace) loadDir(dir string) (*workspaceDir, error) {
+	if d, ok := w.dirs[dir]; ok {
+		return d, nil
+	}
+	d := &workspaceDir{files: map[string][]byte{}}
+	files, err := readFSFiles(os.DirFS(dir), ".", w.opts.Filter, dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, f := range files {
+		d.files[f.name] = f.content
+	}
+	w.dirs[dir] = d
+	return d, nil
+}
+
+// reparse extracts the packages of d again and returns what changed.
+func (w *Workspace) reparse(ctx context.Context, dir string, d *workspaceDir) ChangeSet {
+	files := make([]sourceFile, 0, len(d.files))
+	for name, content := range d.files {
+		files = append(files, sourceFile{name: name, content: content})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	// Positions are resolved to file names and lines during the parse, so each parse gets
+	// its own FileSet rather than growing one for the lifetime of the workspace
+	output, err := w.opts.parseFiles(ctx, token.NewFileSet(), files, w.opts.fileRules())
+	if err != nil {
+		d.err = fmt.Errorf("%s: %w", dir, err)
+		return ChangeSet{}
+	}
+	importPath := importPathForDir(dir)
+	for i := range output.Packages {
+		output.Packages[i].Path = importPath
+	}
+	changes := compareEntities(d.output, output)
+	d.output, d.err = output, nil
+	return changes
+}
+
+func (c *ChangeSet) merge(other ChangeSet) {
+	c.Added = append(c.Added, other.Added...)
+	c.Removed = append(c.Removed, other.Removed...)
+	c.Modified = append(c.Modified, other.Modified...)
+}
+
+type entityState struct {
+	entity Entity
+	body   string // JSON encoding without positions
+}
+
+// compareEntities lists the entities added, removed or modified from old to new, sorted
+// by package, kind and name.
+func compareEntities(old, new *Output) ChangeSet {
+	oldEntities, newEntities := entities(old), entities(new)
+	var changes ChangeSet
+	for _, key := range unionKeys(oldEntities, newEntities) {
+		o, inOld := oldEntities[key]
+		n, inNew := newEntities[key]
+		switch {
+		case !inNew:
+			changes.Removed = append(changes.Removed, o.entity)
+		case !inOld:
+			changes.Added = append(changes.Added, n.entity)
+		case o.body != n.body:
+			changes.Modified = append(changes.Modified, n.entity)
+		}
+	}
+	return changes
+}
+
+// entities indexes the top-level declarations of out by package, kind and name.
+func entities(out *Output) map[string]entityState {
+	found := map[string]entityState{}
+	if out == nil {
+		return found
+	}
+	// Positions move whenever lines are added above an entity; compare without them
+	var stripped Output
+	content, _ := json.Marshal(out)
+	_ = json.Unmarshal(content, &stripped)
+	forEachPosition(&stripped, func(p *Position, _ *string) { *p = Position{} })
+
+	for i, pkg := range out.Packages {
+		bare := stripped.Packages[i]
+		name := entityPackage(pkg)
+		add := func(kind, entityName string, pos Position, value interface{}) {
+			body, _ := json.Marshal(value)
+			found[name+"\x00"+pkg.Package+"\x00"+kind+"\x00"+entityName] = entityState{
+				entity: Entity{Package: name, Kind: kind, Name: entityName, Position: pos},
+				body:   string(body),
+			}
+		}
+		for j, s := range pkg.Structs {
+			add("struct", s.Name, s.Position, bare.Structs[j])
+		}
+		for j, x := range pkg.Interfaces {
+			add("interface", x.Name, x.Position, bare.Interfaces[j])
+		}
+		for j, f := range pkg.Functions {
+			add("function", f.Name, f.Position, bare.Functions[j])
+		}
+		for j, v := range pkg.Variables {
+			add("variable", v.Name, v.Position, bare.Variables[j])
+		}
+		for j, c := range pkg.Constants {
+			add("constant", c.Name, c.Position, bare.Constants[j])
+		}
+		for j, e := range pkg.Enums {
+			add("enum", e.Name, e.Position, bare.Enums[j])
+		}
+		for j, t := range pkg.Tests {
+			add("test", t.Name, t.Position, bare.Tests[j])
+		}
+	}
+	return found
+}
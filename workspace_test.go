@@ -0,0 +1,69 @@
+package structparser
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkspace(t *testing.T) {
+	dirs := writePackages(t, 2)
+	ws := NewWorkspace(ParseOptions{})
+	changes, err := ws.Load(context.Background(), dirs...)
+	require.NoError(t, err)
+	require.Len(t, changes.Added, 2)
+	require.Equal(t, "struct", changes.Added[0].Kind)
+	require.Equal(t, "User", changes.Added[0].Name)
+	require.Equal(t, filepath.Join(dirs[0], "user.go"), changes.Added[0].File)
+
+	// Only the edited package is extracted again
+	user := filepath.Join(dirs[0], "user.go")
+	changes, err = ws.UpdateFile(user, []byte("package pkg00\n\n// User is a user\ntype User struct {\n\tName string\n}\n\nfunc (u *User) Save() error { return nil }\n"))
+	require.NoError(t, err)
+	require.Empty(t, changes.Added)
+	require.Empty(t, changes.Removed)
+	require.Len(t, changes.Modified, 1)
+	require.Equal(t, "pkg00", changes.Modified[0].Package)
+	require.Equal(t, 4, changes.Modified[0].Line)
+
+	// Moving an entity is not a modification
+	changes, err = ws.UpdateFile(user, []byte("package pkg00\n\n\n// User is a user\ntype User struct {\n\tName string\n}\n\nfunc (u *User) Save() error { return nil }\n"))
+	require.NoError(t, err)
+	require.True(t, changes.Empty())
+
+	// Files that are not on disk can be added
+	order := filepath.Join(dirs[0], "order.go")
+	changes, err = ws.UpdateFile(order, []byte("package pkg00\n\ntype Order struct{}\n\nfunc NewID() int { return 0 }\n"))
+	require.NoError(t, err)
+	require.Equal(t, []Entity{
+		{Package: "pkg00", Kind: "function", Name: "NewID", Position: Position{File: order, Line: 5}},
+		{Package: "pkg00", Kind: "struct", Name: "Order", Position: Position{File: order, Line: 3}},
+	}, changes.Added)
+
+	// A broken file keeps the previous entities until it is fixed
+	changes, err = ws.UpdateFile(order, []byte("package pkg00\n\ntype Order struct {\n"))
+	require.Error(t, err)
+	require.True(t, changes.Empty())
+	output := ws.Output()
+	require.Len(t, output.Packages, 2)
+	require.Len(t, output.Packages[0].Structs, 2)
+	require.NotEmpty(t, output.Diagnostics)
+
+	changes, err = ws.RemoveFile(order)
+	require.NoError(t, err)
+	require.Len(t, changes.Removed, 2)
+	require.Empty(t, ws.Output().Diagnostics)
+
+	changes, err = ws.RemoveFile(filepath.Join(dirs[1], "user.go"))
+	require.NoError(t, err)
+	require.Equal(t, []Entity{{Package: "pkg01", Kind: "struct", Name: "User", Position: Position{File: filepath.Join(dirs[1], "user.go"), Line: 3}}}, changes.Removed)
+	require.Len(t, ws.Output().Packages, 1)
+
+	// The file on disk is untouched
+	content, err := os.ReadFile(user)
+	require.NoError(t, err)
+	require.Contains(t, string(content), "type User struct")
+}